@@ -6,9 +6,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/tectiv3/anthropic-go/retry"
@@ -23,7 +25,9 @@ var (
 	DefaultClient        = &http.Client{Timeout: 300 * time.Second}
 	DefaultMaxRetries    = 6
 	DefaultRetryBaseWait = 2 * time.Second
+	DefaultMaxRetryWait  = 60 * time.Second
 	DefaultVersion       = "2023-06-01"
+	DefaultMaxTurns      = 10
 )
 
 func New(opts ...Option) *Client {
@@ -35,7 +39,10 @@ func New(opts ...Option) *Client {
 		maxTokens:     DefaultMaxTokens,
 		maxRetries:    DefaultMaxRetries,
 		retryBaseWait: DefaultRetryBaseWait,
+		maxRetryWait:  DefaultMaxRetryWait,
 		version:       DefaultVersion,
+		maxTurns:      DefaultMaxTurns,
+		logger:        discardLogger,
 	}
 
 	for _, opt := range opts {
@@ -49,25 +56,24 @@ func (p *Client) Name() string {
 	return ProviderName
 }
 
-func (p *Client) Generate(ctx context.Context, messages Messages) (*Response, error) {
-	var request Request
-	if err := p.applyRequestConfig(&request); err != nil {
-		return nil, err
-	}
-	msgs, err := convertMessages(messages)
+// Generate sends the messages and returns the complete response. Any options
+// are applied over the client configuration for this request only.
+func (p *Client) Generate(ctx context.Context, messages Messages, opts ...Option) (*Response, error) {
+	p = p.withOptions(opts)
+	request, err := p.buildRequest(messages)
 	if err != nil {
 		return nil, err
 	}
-	request.Messages = msgs
 
 	body, err := json.Marshal(request)
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling request: %w", err)
 	}
+	betas := p.betaFeatures(request)
 
 	var result Response
 	err = retry.Do(ctx, func() error {
-		req, err := p.createRequest(ctx, body, false)
+		req, err := p.createRequest(ctx, body, false, betas)
 		if err != nil {
 			return err
 		}
@@ -79,47 +85,55 @@ func (p *Client) Generate(ctx context.Context, messages Messages) (*Response, er
 
 		if resp.StatusCode != http.StatusOK {
 			body, _ := io.ReadAll(resp.Body)
-			if resp.StatusCode == 429 {
-				log.Printf("rate limit exceeded, status: %d, body: %s", resp.StatusCode, string(body))
-			}
-			return NewError(resp.StatusCode, string(body))
+			return NewErrorWithHeader(resp.StatusCode, string(body), resp.Header)
 		}
 		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 			return fmt.Errorf("error decoding response: %w", err)
 		}
 		return nil
-	}, retry.WithMaxRetries(p.maxRetries), retry.WithBaseWait(p.retryBaseWait))
+	}, p.retryOptions(ctx)...)
 	if err != nil {
 		return nil, err
 	}
+	logResponse(ctx, p.log(), "anthropic response", &result)
 	if len(result.Content) == 0 {
 		return nil, fmt.Errorf("empty response from anthropic api")
 	}
-
+	if err := p.finishResponse(&result); err != nil {
+		return nil, err
+	}
 	return &result, nil
 }
 
-func (p *Client) Stream(ctx context.Context, messages Messages) (*StreamIterator, error) {
-	var request Request
-	if err := p.applyRequestConfig(&request); err != nil {
-		return nil, err
+// finishResponse applies the client configuration to a response received
+// from the API, extracting the structured output of a response format and
+// prepending the prefill to the text.
+func (p *Client) finishResponse(result *Response) error {
+	if p.ResponseFormat != nil {
+		p.ResponseFormat.extractStructuredOutput(result)
 	}
-	msgs, err := convertMessages(messages)
+	return addPrefill(result.Content, p.Prefill, p.PrefillClosingTag)
+}
+
+// Stream sends the messages and returns an iterator over the response events.
+// Any options are applied over the client configuration for this request only.
+func (p *Client) Stream(ctx context.Context, messages Messages, opts ...Option) (*StreamIterator, error) {
+	p = p.withOptions(opts)
+	request, err := p.buildRequest(messages)
 	if err != nil {
-		return nil, fmt.Errorf("error converting messages: %w", err)
+		return nil, err
 	}
-
-	request.Messages = msgs
 	request.Stream = true
 
 	body, err := json.Marshal(request)
 	if err != nil {
 		return nil, fmt.Errorf("error marshaling request: %w", err)
 	}
+	betas := p.betaFeatures(request)
 
 	var stream *StreamIterator
 	err = retry.Do(ctx, func() error {
-		req, err := p.createRequest(ctx, body, true)
+		req, err := p.createRequest(ctx, body, true, betas)
 		if err != nil {
 			return err
 		}
@@ -130,23 +144,89 @@ func (p *Client) Stream(ctx context.Context, messages Messages) (*StreamIterator
 		if resp.StatusCode != http.StatusOK {
 			body, _ := io.ReadAll(resp.Body)
 			resp.Body.Close()
-			if resp.StatusCode == 429 {
-				log.Printf("rate limit exceeded, status: %d, body: %s", resp.StatusCode, string(body))
-			}
-			return NewError(resp.StatusCode, string(body))
-		}
-		stream = &StreamIterator{
-			body:   resp.Body,
-			reader: NewServerSentEventsReader[Event](resp.Body),
+			return NewErrorWithHeader(resp.StatusCode, string(body), resp.Header)
 		}
+		stream = newStreamIterator(resp.Body, resp.Header, p.Prefill)
+		stream.logger = p.log()
+		stream.ctx = ctx
+		stream.onPartialToolInput = p.PartialToolInputCallback
 		return nil
-	}, retry.WithMaxRetries(p.maxRetries), retry.WithBaseWait(p.retryBaseWait))
+	}, p.retryOptions(ctx)...)
 	if err != nil {
 		return nil, err
 	}
 	return stream, nil
 }
 
+// StreamToResponse streams a response, calling onEvent for each event as it
+// arrives, and returns the complete response once the stream ends. The result
+// is the same as that returned by Generate. The onEvent callback may be nil.
+func (p *Client) StreamToResponse(ctx context.Context, messages Messages, onEvent func(*Event), opts ...Option) (*Response, error) {
+	p = p.withOptions(opts)
+	stream, err := p.Stream(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+	for stream.Next() {
+		if onEvent != nil {
+			onEvent(stream.Event())
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+	if stream.accumulateErr != nil {
+		return nil, stream.accumulateErr
+	}
+	result := stream.Response()
+	if result == nil || len(result.Content) == 0 {
+		return nil, fmt.Errorf("empty response from anthropic api")
+	}
+	if p.ResponseFormat != nil {
+		p.ResponseFormat.extractStructuredOutput(result)
+	}
+	// The prefill was already prepended to the text while streaming
+	if p.Prefill != "" && p.PrefillClosingTag != "" {
+		if !strings.Contains(result.Message().Text(), p.PrefillClosingTag) {
+			return nil, fmt.Errorf("prefill closing tag not found")
+		}
+	}
+	return result, nil
+}
+
+// withOptions returns a copy of the client with the given options applied, or
+// the client itself if there are none. This allows options to be set for a
+// single request without modifying a client that may be shared.
+func (p *Client) withOptions(opts []Option) *Client {
+	if len(opts) == 0 {
+		return p
+	}
+	c := *p
+	c.Apply(opts...)
+	return &c
+}
+
+// buildRequest creates a request for the given messages using the client
+// configuration. If a prefill is configured, it is sent as a trailing
+// assistant message.
+func (p *Client) buildRequest(messages Messages) (*Request, error) {
+	var request Request
+	if err := p.applyRequestConfig(&request); err != nil {
+		return nil, err
+	}
+	msgs, err := convertMessages(messages)
+	if err != nil {
+		return nil, fmt.Errorf("error converting messages: %w", err)
+	}
+	if p.Prefill != "" {
+		msgs = append(msgs, NewAssistantTextMessage(p.Prefill))
+	}
+	request.Messages = msgs
+	p.applyCaching(&request)
+	return &request, nil
+}
+
 func convertMessages(messages []*Message) ([]*Message, error) {
 	messageCount := len(messages)
 	if messageCount == 0 {
@@ -174,6 +254,13 @@ func convertMessages(messages []*Message) ([]*Message, error) {
 					IsError:      c.IsError,
 					CacheControl: c.CacheControl,
 				})
+			case *ThinkingContent:
+				// Thinking blocks must be passed back with their signature
+				// intact so that the API can verify them, but without an ID
+				copiedContent = append(copiedContent, &ThinkingContent{
+					Thinking:  c.Thinking,
+					Signature: c.Signature,
+				})
 			case *DocumentContent:
 				// Handle DocumentContent with file IDs for Anthropic API compatibility
 				if c.Source != nil && c.Source.Type == ContentSourceTypeFile && c.Source.FileID != "" {
@@ -209,6 +296,17 @@ func (p *Client) applyRequestConfig(req *Request) error {
 	req.Model = p.model
 	req.MaxTokens = &p.maxTokens
 
+	// Limits are only enforced for models known to the capability registry
+	capabilities, knownModel := LookupModelCapabilities(p.model)
+	maxOutputTokens := capabilities.MaxOutputTokens
+	if capabilities.BetaMaxOutputTokens > 0 && p.hasBetaFeature(BetaOutput128K) {
+		maxOutputTokens = capabilities.BetaMaxOutputTokens
+	}
+	if knownModel && maxOutputTokens > 0 && p.maxTokens > maxOutputTokens {
+		return fmt.Errorf("max tokens (%d) exceeds the limit of %d for model %s",
+			p.maxTokens, maxOutputTokens, p.model)
+	}
+
 	if len(p.Tools) > 0 {
 		var tools []map[string]any
 		for _, tool := range p.Tools {
@@ -245,34 +343,283 @@ func (p *Client) applyRequestConfig(req *Request) error {
 		}
 	}
 
+	if p.ResponseFormat != nil {
+		toolConfig, err := p.ResponseFormat.toolConfiguration()
+		if err != nil {
+			return err
+		}
+		if toolConfig != nil {
+			// The response is a forced tool call, so there is no text to prefill
+			if p.Prefill != "" {
+				return fmt.Errorf("prefill is not supported with a %s response format", p.ResponseFormat.Type)
+			}
+			req.Tools = append(req.Tools, toolConfig)
+			req.ToolChoice = &ToolChoice{
+				Type: ToolChoiceTypeTool,
+				Name: p.ResponseFormat.toolName(),
+			}
+		}
+	}
+
 	if len(p.MCPServers) > 0 {
 		req.MCPServers = p.MCPServers
 	}
 
-	req.Temperature = p.Temperature
-	req.System = p.SystemPrompt
+	if err := p.applySamplingConfig(req); err != nil {
+		return err
+	}
+	req.Metadata = p.Metadata
+	if p.SystemPrompt != "" {
+		req.System = append(req.System, &TextContent{Text: p.SystemPrompt})
+	}
+	for i, block := range p.SystemBlocks {
+		if block == nil || block.Text == "" {
+			return fmt.Errorf("empty system block detected (index %d)", i)
+		}
+		req.System = append(req.System, block)
+	}
+
+	thinking, err := p.thinkingConfig()
+	if err != nil {
+		return err
+	}
+	if thinking != nil {
+		if knownModel && !capabilities.SupportsThinking {
+			return fmt.Errorf("model %s does not support reasoning", p.model)
+		}
+		if thinking.BudgetTokens >= *req.MaxTokens {
+			return fmt.Errorf("reasoning budget (%d) must be less than max tokens (%d)",
+				thinking.BudgetTokens, *req.MaxTokens)
+		}
+		// Extended thinking is incompatible with temperature changes, with
+		// forcing the use of a tool and with prefilling the response
+		if req.Temperature != nil && *req.Temperature != 1 {
+			return fmt.Errorf("temperature must be 1 when reasoning is enabled")
+		}
+		if req.TopK != nil {
+			return fmt.Errorf("top k is not supported when reasoning is enabled")
+		}
+		if req.TopP != nil && *req.TopP < 0.95 {
+			return fmt.Errorf("top p must be at least 0.95 when reasoning is enabled")
+		}
+		if req.ToolChoice != nil &&
+			(req.ToolChoice.Type == ToolChoiceTypeAny || req.ToolChoice.Type == ToolChoiceTypeTool) {
+			return fmt.Errorf("tool choice %q is not supported when reasoning is enabled", req.ToolChoice.Type)
+		}
+		if p.Prefill != "" {
+			return fmt.Errorf("prefill is not supported when reasoning is enabled")
+		}
+		req.Thinking = thinking
+	}
 
 	return nil
 }
 
+// applySamplingConfig validates the sampling parameters and sets them on the
+// request. Client fields that have no equivalent in the Anthropic API result
+// in an error rather than being silently ignored.
+func (p *Client) applySamplingConfig(req *Request) error {
+	switch {
+	case p.PresencePenalty != nil:
+		return fmt.Errorf("presence penalty is not supported by the anthropic api")
+	case p.FrequencyPenalty != nil:
+		return fmt.Errorf("frequency penalty is not supported by the anthropic api")
+	case p.PreviousResponseID != "":
+		return fmt.Errorf("previous response id is not supported by the anthropic api")
+	}
+
+	if p.Temperature != nil && (*p.Temperature < 0 || *p.Temperature > 1) {
+		return fmt.Errorf("temperature (%g) must be between 0 and 1", *p.Temperature)
+	}
+	if p.TopP != nil && (*p.TopP < 0 || *p.TopP > 1) {
+		return fmt.Errorf("top p (%g) must be between 0 and 1", *p.TopP)
+	}
+	if p.TopK != nil && *p.TopK <= 0 {
+		return fmt.Errorf("top k (%d) must be positive", *p.TopK)
+	}
+	for i, sequence := range p.StopSequences {
+		if strings.TrimSpace(sequence) == "" {
+			return fmt.Errorf("empty stop sequence detected (index %d)", i)
+		}
+	}
+	switch p.ServiceTier {
+	case "", ServiceTierAuto, ServiceTierStandardOnly:
+	default:
+		return fmt.Errorf("invalid service tier: %q", p.ServiceTier)
+	}
+
+	req.Temperature = p.Temperature
+	req.TopP = p.TopP
+	req.TopK = p.TopK
+	req.StopSequences = p.StopSequences
+	req.ServiceTier = p.ServiceTier
+	return nil
+}
+
+// thinkingConfig returns the extended thinking configuration derived from the
+// reasoning budget or effort, or nil if reasoning is not enabled.
+func (p *Client) thinkingConfig() (*Thinking, error) {
+	var budget int
+	if p.ReasoningBudget != nil {
+		budget = *p.ReasoningBudget
+	} else if p.ReasoningEffort != "" {
+		if !p.ReasoningEffort.IsValid() {
+			return nil, fmt.Errorf("invalid reasoning effort: %q", p.ReasoningEffort)
+		}
+		budget = ReasoningEffortBudgets[p.ReasoningEffort]
+	} else {
+		return nil, nil
+	}
+	if budget < MinReasoningBudget {
+		return nil, fmt.Errorf("reasoning budget (%d) must be at least %d", budget, MinReasoningBudget)
+	}
+	return &Thinking{Type: ThinkingTypeEnabled, BudgetTokens: budget}, nil
+}
+
+// retryOptions returns the options used to retry failed API requests.
+func (p *Client) retryOptions(ctx context.Context) []retry.Option {
+	return []retry.Option{
+		retry.WithMaxRetries(p.maxRetries),
+		retry.WithBaseWait(p.retryBaseWait),
+		retry.WithMaxWait(p.maxRetryWait),
+		retry.WithOnRetry(func(attempt int, wait time.Duration, err error) {
+			p.logRetry(ctx, attempt, wait, err)
+		}),
+	}
+}
+
 // createRequest creates an HTTP request with appropriate headers for Anthropic API calls
-func (p *Client) createRequest(ctx context.Context, body []byte, isStreaming bool) (*http.Request, error) {
-	req, err := http.NewRequestWithContext(ctx, "POST", p.endpoint, bytes.NewBuffer(body))
+func (p *Client) createRequest(ctx context.Context, body []byte, isStreaming bool, betas []string) (*http.Request, error) {
+	header := http.Header{}
+	header.Set("content-type", "application/json")
+	if isStreaming {
+		header.Set("accept", "text/event-stream")
+	}
+	addBetaFeatures(header, betas)
+	return p.newAPIRequest(ctx, http.MethodPost, p.endpoint, body, header)
+}
+
+// apiURL returns the URL of an API path such as "/files". The base URL is
+// derived from the messages endpoint by removing its "/messages" suffix.
+func (p *Client) apiURL(path string) string {
+	return strings.TrimSuffix(p.endpoint, "/messages") + path
+}
+
+// ListParams are used to paginate through the results of the list endpoints,
+// such as files, batches and models. To fetch the next page, set AfterID to
+// the LastID of the previous page.
+type ListParams struct {
+	BeforeID string
+	AfterID  string
+	Limit    int
+}
+
+// withListParams returns the endpoint with the pagination params added as a
+// query string. The params may be nil.
+func withListParams(endpoint string, params *ListParams) string {
+	if params == nil {
+		return endpoint
+	}
+	query := url.Values{}
+	if params.BeforeID != "" {
+		query.Set("before_id", params.BeforeID)
+	}
+	if params.AfterID != "" {
+		query.Set("after_id", params.AfterID)
+	}
+	if params.Limit > 0 {
+		query.Set("limit", strconv.Itoa(params.Limit))
+	}
+	if len(query) == 0 {
+		return endpoint
+	}
+	return endpoint + "?" + query.Encode()
+}
+
+// newAPIRequest creates an HTTP request with the authentication and version
+// headers set, followed by the given headers and the client's custom headers.
+func (p *Client) newAPIRequest(ctx context.Context, method, url string, body []byte, header http.Header) (*http.Request, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %w", err)
 	}
 	req.Header.Set("x-api-key", p.apiKey)
 	req.Header.Set("anthropic-version", p.version)
-	req.Header.Set("content-type", "application/json")
 
-	if isStreaming {
-		req.Header.Set("accept", "text/event-stream")
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
 	}
-
 	for key, values := range p.RequestHeaders {
 		for _, value := range values {
 			req.Header.Add(key, value)
 		}
 	}
+	mergeBetaHeader(req.Header)
+	p.logRequest(ctx, req)
 	return req, nil
 }
+
+// doAPIRequest sends a request to the API, retrying recoverable errors, and
+// returns the successful response. The caller must close the response body.
+func (p *Client) doAPIRequest(ctx context.Context, method, url string, body []byte, header http.Header) (*http.Response, error) {
+	return p.sendAPIRequest(ctx, func() (*http.Request, error) {
+		return p.newAPIRequest(ctx, method, url, body, header)
+	}, p.retryOptions(ctx)...)
+}
+
+// sendAPIRequest sends the requests returned by newRequest until one succeeds
+// or a non-recoverable error occurs, and returns the successful response. The
+// caller must close the response body.
+func (p *Client) sendAPIRequest(ctx context.Context, newRequest func() (*http.Request, error), opts ...retry.Option) (*http.Response, error) {
+	var result *http.Response
+	err := retry.Do(ctx, func() error {
+		req, err := newRequest()
+		if err != nil {
+			return err
+		}
+		resp, err := p.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("error making request: %w", err)
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return NewErrorWithHeader(resp.StatusCode, string(body), resp.Header)
+		}
+		result = resp
+		return nil
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// doJSONRequest sends a request to the API and decodes the JSON response
+// into result, unless result is nil.
+func (p *Client) doJSONRequest(ctx context.Context, method, url string, body []byte, header http.Header, result any) error {
+	resp, err := p.doAPIRequest(ctx, method, url, body, header)
+	if err != nil {
+		return err
+	}
+	return decodeJSONResponse(resp, result)
+}
+
+// decodeJSONResponse decodes the JSON response into result, unless result is
+// nil, and closes the response body.
+func decodeJSONResponse(resp *http.Response, result any) error {
+	defer resp.Body.Close()
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	return nil
+}
@@ -1,7 +1,11 @@
 package anthropic
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -222,3 +226,375 @@ func TestNew_WithTools(t *testing.T) {
 		t.Errorf("Expected SystemPrompt 'You are helpful.', got %q", client.SystemPrompt)
 	}
 }
+
+func TestApplyRequestConfig_ReasoningEffort(t *testing.T) {
+	client := New(
+		WithMaxTokens(8192),
+		WithReasoningEffort(ReasoningEffortMedium),
+	)
+
+	var request Request
+	if err := client.applyRequestConfig(&request); err != nil {
+		t.Fatalf("applyRequestConfig failed: %v", err)
+	}
+	if request.Thinking == nil {
+		t.Fatal("Expected thinking to be enabled")
+	}
+	if request.Thinking.Type != ThinkingTypeEnabled {
+		t.Errorf("Expected thinking type %q, got %q", ThinkingTypeEnabled, request.Thinking.Type)
+	}
+	if request.Thinking.BudgetTokens != ReasoningEffortBudgets[ReasoningEffortMedium] {
+		t.Errorf("Expected budget %d, got %d", ReasoningEffortBudgets[ReasoningEffortMedium], request.Thinking.BudgetTokens)
+	}
+}
+
+func TestApplyRequestConfig_ReasoningBudget(t *testing.T) {
+	client := New(
+		WithMaxTokens(8192),
+		WithReasoningEffort(ReasoningEffortHigh),
+		WithReasoningBudget(2000),
+	)
+
+	var request Request
+	if err := client.applyRequestConfig(&request); err != nil {
+		t.Fatalf("applyRequestConfig failed: %v", err)
+	}
+	if request.Thinking == nil || request.Thinking.BudgetTokens != 2000 {
+		t.Errorf("Expected explicit budget to take precedence, got %+v", request.Thinking)
+	}
+}
+
+func TestApplyRequestConfig_ReasoningValidation(t *testing.T) {
+	temperature := 0.5
+	tests := []struct {
+		name   string
+		client *Client
+	}{
+		{"budget above max tokens", New(WithMaxTokens(2048), WithReasoningBudget(4096))},
+		{"budget below minimum", New(WithReasoningBudget(100))},
+		{"invalid effort", New(WithReasoningEffort("extreme"))},
+		{"temperature", New(WithReasoningBudget(2048), func(c *Client) { c.Temperature = &temperature })},
+		{"forced tool", New(
+			WithReasoningBudget(2048),
+			WithTools(NewToolDefinition().WithName("test_tool").WithSchema(&Schema{Type: Object})),
+			WithToolChoice(ToolChoiceAny),
+		)},
+		{"prefill", New(WithReasoningBudget(2048), WithPrefill("{", ""))},
+	}
+
+	for _, test := range tests {
+		var request Request
+		if err := test.client.applyRequestConfig(&request); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
+
+func TestApplyRequestConfig_SystemBlocks(t *testing.T) {
+	client := New(
+		WithSystemPrompt("You are a helpful assistant."),
+		WithSystemBlocks(
+			&TextContent{Text: "Reference manual...", CacheControl: &CacheControl{Type: CacheControlTypeEphemeral}},
+			&TextContent{Text: "Today is Monday."},
+		),
+	)
+
+	var request Request
+	if err := client.applyRequestConfig(&request); err != nil {
+		t.Fatalf("applyRequestConfig failed: %v", err)
+	}
+	if len(request.System) != 3 {
+		t.Fatalf("Expected 3 system blocks, got %d", len(request.System))
+	}
+	if request.System[0].Text != "You are a helpful assistant." || request.System[1].CacheControl == nil {
+		t.Errorf("Unexpected system blocks: %+v", request.System)
+	}
+
+	body, err := json.Marshal(&request)
+	if err != nil {
+		t.Fatalf("Failed to marshal request: %v", err)
+	}
+	expected := `"system":[{"type":"text","text":"You are a helpful assistant."},` +
+		`{"type":"text","text":"Reference manual...","cache_control":{"type":"ephemeral"}},` +
+		`{"type":"text","text":"Today is Monday."}]`
+	if !strings.Contains(string(body), expected) {
+		t.Errorf("Expected system blocks %s in request, got %s", expected, body)
+	}
+
+	client = New(WithSystemBlocks(&TextContent{Text: "ok"}, &TextContent{}))
+	if err := client.applyRequestConfig(&Request{}); err == nil {
+		t.Error("Expected error for empty system block")
+	}
+}
+
+func TestConvertMessages_ThinkingSignature(t *testing.T) {
+	messages := []*Message{
+		NewUserTextMessage("Hello"),
+		{
+			Role: Assistant,
+			Content: []Content{
+				&ThinkingContent{ID: "th_1", Thinking: "Let me think", Signature: "sig_123"},
+				&TextContent{Text: "Hi"},
+			},
+		},
+	}
+
+	result, err := convertMessages(messages)
+	if err != nil {
+		t.Fatalf("convertMessages failed: %v", err)
+	}
+
+	thinking, ok := result[1].Content[0].(*ThinkingContent)
+	if !ok {
+		t.Fatal("Expected first block to be ThinkingContent")
+	}
+	if thinking.Signature != "sig_123" || thinking.Thinking != "Let me think" {
+		t.Errorf("Thinking content not preserved: %+v", thinking)
+	}
+	if thinking.ID != "" {
+		t.Errorf("Expected thinking ID to be omitted, got %q", thinking.ID)
+	}
+}
+
+func TestClient_Generate_Prefill(t *testing.T) {
+	var requests []map[string]any
+	server := newMockServer(t, []string{
+		`{"id":"msg_1","type":"message","role":"assistant","stop_reason":"end_turn","content":[
+			{"type":"text","text":"\"answer\": 42}"}
+		]}`,
+	}, &requests)
+
+	client := New(
+		WithAPIKey("test-key"),
+		WithEndpoint(server.URL),
+		WithPrefill("{", "}"),
+	)
+
+	messages := Messages{NewUserTextMessage("Respond in JSON")}
+	response, err := client.Generate(context.Background(), messages)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if response.Message().Text() != `{"answer": 42}` {
+		t.Errorf("Expected prefilled text, got %q", response.Message().Text())
+	}
+	if len(messages) != 1 {
+		t.Errorf("Input messages should not be modified, got %d messages", len(messages))
+	}
+
+	sent := requests[0]["messages"].([]any)
+	if len(sent) != 2 {
+		t.Fatalf("Expected 2 messages to be sent, got %d", len(sent))
+	}
+	last := sent[1].(map[string]any)
+	if last["role"] != "assistant" {
+		t.Errorf("Expected trailing assistant message, got role %v", last["role"])
+	}
+	if text := last["content"].([]any)[0].(map[string]any)["text"]; text != "{" {
+		t.Errorf("Expected prefill text '{', got %v", text)
+	}
+}
+
+func TestClient_Generate_PrefillClosingTagMissing(t *testing.T) {
+	server := newMockServer(t, []string{
+		`{"id":"msg_1","type":"message","role":"assistant","stop_reason":"end_turn","content":[
+			{"type":"text","text":"no closing tag"}
+		]}`,
+	}, nil)
+
+	client := New(
+		WithAPIKey("test-key"),
+		WithEndpoint(server.URL),
+		WithPrefill("<result>", "</result>"),
+	)
+
+	_, err := client.Generate(context.Background(), Messages{NewUserTextMessage("Hi")})
+	if err == nil {
+		t.Error("Expected error when closing tag is missing")
+	}
+}
+
+func TestClient_Stream_Prefill(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "text/event-stream")
+		w.Write([]byte(`event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[]}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"42</result>"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`))
+	}))
+	defer server.Close()
+
+	client := New(
+		WithAPIKey("test-key"),
+		WithEndpoint(server.URL),
+		WithPrefill("<result>", "</result>"),
+	)
+
+	stream, err := client.Stream(context.Background(), Messages{NewUserTextMessage("Hi")})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	accumulator := NewResponseAccumulator()
+	for stream.Next() {
+		if err := accumulator.AddEvent(stream.Event()); err != nil {
+			t.Fatalf("AddEvent failed: %v", err)
+		}
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("Stream error: %v", err)
+	}
+	if text := accumulator.Response().Message().Text(); text != "<result>42</result>" {
+		t.Errorf("Expected prefilled text, got %q", text)
+	}
+}
+
+func TestClient_Generate_PerCallOptions(t *testing.T) {
+	var bodies []map[string]any
+	var headers []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		bodies = append(bodies, body)
+		headers = append(headers, r.Header.Clone())
+		w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"Hi"}]}`))
+	}))
+	defer server.Close()
+
+	client := New(
+		WithAPIKey("test-key"),
+		WithEndpoint(server.URL),
+		WithSystemPrompt("Default system"),
+		WithHeader("X-Team", "search"),
+	)
+	messages := Messages{NewUserTextMessage("Hello")}
+	ctx := context.Background()
+
+	if _, err := client.Generate(ctx, messages,
+		WithModel("claude-3-5-haiku-latest"),
+		WithMaxTokens(1000),
+		WithTemperature(0.2),
+		WithSystemPrompt("Per-call system"),
+		WithMetadata(&Metadata{UserID: "user-123"}),
+		WithHeader("X-Request-Source", "test"),
+	); err != nil {
+		t.Fatalf("Generate with options failed: %v", err)
+	}
+	if _, err := client.Generate(ctx, messages); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	withOptions, defaults := bodies[0], bodies[1]
+	if withOptions["model"] != "claude-3-5-haiku-latest" || withOptions["max_tokens"] != 1000.0 || withOptions["temperature"] != 0.2 {
+		t.Errorf("Expected per-call options in request, got %v", withOptions)
+	}
+	if system := withOptions["system"].([]any)[0].(map[string]any)["text"]; system != "Per-call system" {
+		t.Errorf("Expected per-call system prompt, got %v", system)
+	}
+	if metadata, _ := withOptions["metadata"].(map[string]any); metadata["user_id"] != "user-123" {
+		t.Errorf("Expected metadata in request, got %v", withOptions["metadata"])
+	}
+	if headers[0].Get("X-Request-Source") != "test" || headers[0].Get("X-Team") != "search" {
+		t.Errorf("Expected per-call and client headers, got %v", headers[0])
+	}
+
+	if defaults["model"] != DefaultModel || defaults["temperature"] != nil || defaults["metadata"] != nil {
+		t.Errorf("Expected client defaults in second request, got %v", defaults)
+	}
+	if headers[1].Get("X-Request-Source") != "" {
+		t.Error("Per-call header should not persist on the client")
+	}
+	if client.model != DefaultModel || client.Temperature != nil || client.SystemPrompt != "Default system" {
+		t.Error("Per-call options should not modify the client")
+	}
+	if len(client.RequestHeaders) != 1 {
+		t.Errorf("Expected the client headers to be unchanged, got %v", client.RequestHeaders)
+	}
+}
+
+func TestApplyRequestConfig_Sampling(t *testing.T) {
+	client := New(
+		WithTemperature(0.7),
+		WithTopP(0.9),
+		WithTopK(40),
+		WithStopSequences("\n\nHuman:", "END"),
+		WithServiceTier(ServiceTierStandardOnly),
+		WithMetadata(&Metadata{}),
+		WithUserID("user-123"),
+	)
+
+	var request Request
+	if err := client.applyRequestConfig(&request); err != nil {
+		t.Fatalf("applyRequestConfig failed: %v", err)
+	}
+	body, err := json.Marshal(&request)
+	if err != nil {
+		t.Fatalf("Failed to marshal request: %v", err)
+	}
+	for _, expected := range []string{
+		`"temperature":0.7`,
+		`"top_p":0.9`,
+		`"top_k":40`,
+		`"stop_sequences":["\n\nHuman:","END"]`,
+		`"metadata":{"user_id":"user-123"}`,
+		`"service_tier":"standard_only"`,
+	} {
+		if !strings.Contains(string(body), expected) {
+			t.Errorf("Expected %s in request, got %s", expected, body)
+		}
+	}
+}
+
+func TestApplyRequestConfig_SamplingValidation(t *testing.T) {
+	penalty := 0.5
+	tests := []struct {
+		name    string
+		client  *Client
+		wantErr string
+	}{
+		{"presence penalty", New(func(c *Client) { c.PresencePenalty = &penalty }), "presence penalty is not supported"},
+		{"frequency penalty", New(func(c *Client) { c.FrequencyPenalty = &penalty }), "frequency penalty is not supported"},
+		{"previous response id", New(func(c *Client) { c.PreviousResponseID = "resp_1" }), "previous response id is not supported"},
+		{"temperature range", New(WithTemperature(1.5)), "temperature"},
+		{"top p range", New(WithTopP(1.5)), "top p"},
+		{"top k", New(WithTopK(0)), "top k"},
+		{"empty stop sequence", New(WithStopSequences("END", " ")), "empty stop sequence"},
+		{"service tier", New(WithServiceTier("priority")), "invalid service tier"},
+		{"top k with reasoning", New(WithMaxTokens(8192), WithReasoningBudget(2048), WithTopK(10)), "top k"},
+		{"top p with reasoning", New(WithMaxTokens(8192), WithReasoningBudget(2048), WithTopP(0.5)), "top p"},
+	}
+	for _, test := range tests {
+		err := test.client.applyRequestConfig(&Request{})
+		if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("%s: expected error containing %q, got %v", test.name, test.wantErr, err)
+		}
+	}
+}
+
+func TestClient_Generate_StopSequence(t *testing.T) {
+	server := newMockServer(t, []string{
+		`{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"1, 2, 3"}],"stop_reason":"stop_sequence","stop_sequence":"4"}`,
+	}, nil)
+	client := New(WithAPIKey("test-key"), WithEndpoint(server.URL), WithStopSequences("4"))
+
+	response, err := client.Generate(context.Background(), Messages{NewUserTextMessage("Count to ten")})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if response.StopReason != StopReasonStopSequence || response.StopSequence == nil || *response.StopSequence != "4" {
+		t.Errorf("Expected stop sequence %q, got reason %q and %v", "4", response.StopReason, response.StopSequence)
+	}
+}
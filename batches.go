@@ -0,0 +1,313 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// BatchProcessingStatus indicates the processing status of a message batch.
+type BatchProcessingStatus string
+
+const (
+	BatchProcessingStatusInProgress BatchProcessingStatus = "in_progress"
+	BatchProcessingStatusCanceling  BatchProcessingStatus = "canceling"
+	BatchProcessingStatusEnded      BatchProcessingStatus = "ended"
+)
+
+// BatchResultType indicates the outcome of one request in a message batch.
+type BatchResultType string
+
+const (
+	BatchResultTypeSucceeded BatchResultType = "succeeded"
+	BatchResultTypeErrored   BatchResultType = "errored"
+	BatchResultTypeCanceled  BatchResultType = "canceled"
+	BatchResultTypeExpired   BatchResultType = "expired"
+)
+
+// BatchRequest is one request to include in a message batch. The custom ID
+// is used to match the request to its result. Any options are applied over
+// the client configuration for this request only.
+type BatchRequest struct {
+	CustomID string
+	Messages Messages
+	Options  []Option
+}
+
+/* Example:
+{
+  "id": "msgbatch_013Zva2CMHLNnXjNJJKqJ2EF",
+  "type": "message_batch",
+  "processing_status": "in_progress",
+  "request_counts": {
+    "processing": 100,
+    "succeeded": 50,
+    "errored": 30,
+    "canceled": 10,
+    "expired": 10
+  },
+  "ended_at": "2024-08-20T18:37:24.100435Z",
+  "created_at": "2024-08-20T18:37:24.100435Z",
+  "expires_at": "2024-08-20T18:37:24.100435Z",
+  "archived_at": "2024-08-20T18:37:24.100435Z",
+  "cancel_initiated_at": "2024-08-20T18:37:24.100435Z",
+  "results_url": "https://api.anthropic.com/v1/messages/batches/msgbatch_013Zva2CMHLNnXjNJJKqJ2EF/results"
+}
+*/
+
+// BatchRequestCounts tallies the requests in a batch by status.
+type BatchRequestCounts struct {
+	Processing int `json:"processing"`
+	Succeeded  int `json:"succeeded"`
+	Errored    int `json:"errored"`
+	Canceled   int `json:"canceled"`
+	Expired    int `json:"expired"`
+}
+
+// MessageBatch describes a message batch and its processing status.
+type MessageBatch struct {
+	ID                string                `json:"id"`
+	Type              string                `json:"type"`
+	ProcessingStatus  BatchProcessingStatus `json:"processing_status"`
+	RequestCounts     BatchRequestCounts    `json:"request_counts"`
+	EndedAt           *time.Time            `json:"ended_at,omitempty"`
+	CreatedAt         time.Time             `json:"created_at"`
+	ExpiresAt         time.Time             `json:"expires_at"`
+	ArchivedAt        *time.Time            `json:"archived_at,omitempty"`
+	CancelInitiatedAt *time.Time            `json:"cancel_initiated_at,omitempty"`
+	ResultsURL        string                `json:"results_url,omitempty"`
+}
+
+// IsEnded returns true if the batch has finished processing, in which case
+// its results are available.
+func (b *MessageBatch) IsEnded() bool {
+	return b.ProcessingStatus == BatchProcessingStatusEnded
+}
+
+// MessageBatchList is one page of batches returned by BatchesClient.List.
+type MessageBatchList struct {
+	Data    []*MessageBatch `json:"data"`
+	FirstID string          `json:"first_id"`
+	LastID  string          `json:"last_id"`
+	HasMore bool            `json:"has_more"`
+}
+
+// BatchResult is the result of one request in a message batch. Response is
+// set if the request succeeded and Error is set if it errored.
+type BatchResult struct {
+	CustomID string
+	Type     BatchResultType
+	Response *Response
+	Error    *ClientError
+}
+
+/* Examples:
+{"custom_id":"req-1","result":{"type":"succeeded","message":{"id":"msg_1","type":"message",...}}}
+{"custom_id":"req-2","result":{"type":"errored","error":{"type":"error","error":{"type":"invalid_request_error","message":"..."}}}}
+{"custom_id":"req-3","result":{"type":"expired"}}
+*/
+
+type batchResultLine struct {
+	CustomID string `json:"custom_id"`
+	Result   struct {
+		Type    BatchResultType   `json:"type"`
+		Message *Response         `json:"message,omitempty"`
+		Error   *apiErrorEnvelope `json:"error,omitempty"`
+	} `json:"result"`
+}
+
+// BatchesClient is used to process many requests asynchronously with the
+// Message Batches API. Learn more:
+// https://docs.anthropic.com/en/docs/build-with-claude/batch-processing
+type BatchesClient struct {
+	client *Client
+}
+
+// Batches returns a client for the Message Batches API.
+func (p *Client) Batches() *BatchesClient {
+	return &BatchesClient{client: p}
+}
+
+func (b *BatchesClient) url(path string) string {
+	return b.client.apiURL("/messages/batches" + path)
+}
+
+// Create creates a message batch. Each request is built from the client
+// configuration in the same way as requests made by Generate.
+func (b *BatchesClient) Create(ctx context.Context, requests []*BatchRequest) (*MessageBatch, error) {
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("no batch requests provided")
+	}
+	type batchRequestParams struct {
+		CustomID string   `json:"custom_id"`
+		Params   *Request `json:"params"`
+	}
+	params := make([]*batchRequestParams, 0, len(requests))
+	header := http.Header{}
+	for _, request := range requests {
+		if request.CustomID == "" {
+			return nil, fmt.Errorf("batch request is missing a custom id")
+		}
+		client := b.client.withOptions(request.Options)
+		built, err := client.buildRequest(request.Messages)
+		if err != nil {
+			return nil, fmt.Errorf("batch request %s: %w", request.CustomID, err)
+		}
+		params = append(params, &batchRequestParams{CustomID: request.CustomID, Params: built})
+		addBetaFeatures(header, client.betaFeatures(built))
+	}
+	body, err := json.Marshal(map[string]any{"requests": params})
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request: %w", err)
+	}
+	header.Set("content-type", "application/json")
+	var batch MessageBatch
+	if err := b.client.doJSONRequest(ctx, http.MethodPost, b.url(""), body, header, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// Get returns the message batch with the given ID.
+func (b *BatchesClient) Get(ctx context.Context, batchID string) (*MessageBatch, error) {
+	var batch MessageBatch
+	if err := b.client.doJSONRequest(ctx, http.MethodGet, b.url("/"+url.PathEscape(batchID)), nil, nil, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// Wait polls the message batch with the given ID at the given interval until
+// it has ended, and then returns it.
+func (b *BatchesClient) Wait(ctx context.Context, batchID string, interval time.Duration) (*MessageBatch, error) {
+	for {
+		batch, err := b.Get(ctx, batchID)
+		if err != nil {
+			return nil, err
+		}
+		if batch.IsEnded() {
+			return batch, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// List returns one page of message batches, most recent first. The params
+// may be nil.
+func (b *BatchesClient) List(ctx context.Context, params *ListParams) (*MessageBatchList, error) {
+	endpoint := withListParams(b.url(""), params)
+	var list MessageBatchList
+	if err := b.client.doJSONRequest(ctx, http.MethodGet, endpoint, nil, nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// Cancel initiates cancellation of the message batch with the given ID.
+func (b *BatchesClient) Cancel(ctx context.Context, batchID string) (*MessageBatch, error) {
+	var batch MessageBatch
+	endpoint := b.url("/" + url.PathEscape(batchID) + "/cancel")
+	if err := b.client.doJSONRequest(ctx, http.MethodPost, endpoint, nil, nil, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// Delete deletes the message batch with the given ID. Batches must finish
+// processing before they can be deleted.
+func (b *BatchesClient) Delete(ctx context.Context, batchID string) error {
+	return b.client.doJSONRequest(ctx, http.MethodDelete, b.url("/"+url.PathEscape(batchID)), nil, nil, nil)
+}
+
+// Results streams the results of the ended message batch with the given ID.
+// The results are not guaranteed to be in the same order as the requests.
+// Successful responses are processed in the same way as those returned by
+// Generate, using the client configuration and the options of the request
+// with the same custom ID, if it is given.
+func (b *BatchesClient) Results(ctx context.Context, batchID string, requests ...*BatchRequest) (*BatchResultIterator, error) {
+	endpoint := b.url("/" + url.PathEscape(batchID) + "/results")
+	resp, err := b.client.doAPIRequest(ctx, http.MethodGet, endpoint, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	options := make(map[string][]Option, len(requests))
+	for _, request := range requests {
+		options[request.CustomID] = request.Options
+	}
+	return &BatchResultIterator{
+		body:    resp.Body,
+		decoder: json.NewDecoder(resp.Body),
+		client:  b.client,
+		options: options,
+	}, nil
+}
+
+// BatchResultIterator reads the results of a message batch one at a time.
+type BatchResultIterator struct {
+	body      io.ReadCloser
+	decoder   *json.Decoder
+	client    *Client
+	options   map[string][]Option
+	current   *BatchResult
+	err       error
+	closeOnce sync.Once
+}
+
+// Next advances to the next result. Returns false when all results have been
+// read or an error occurs.
+func (it *BatchResultIterator) Next() bool {
+	var line batchResultLine
+	if err := it.decoder.Decode(&line); err != nil {
+		if !errors.Is(err, io.EOF) {
+			it.err = fmt.Errorf("error decoding batch result: %w", err)
+		}
+		it.Close()
+		return false
+	}
+	result := &BatchResult{
+		CustomID: line.CustomID,
+		Type:     line.Result.Type,
+		Response: line.Result.Message,
+	}
+	if line.Result.Error != nil && line.Result.Error.Error != nil {
+		result.Error = newAPIError(line.Result.Error.Error, nil)
+	}
+	if result.Response != nil {
+		client := it.client.withOptions(it.options[result.CustomID])
+		if err := client.finishResponse(result.Response); err != nil {
+			it.err = fmt.Errorf("batch result %s: %w", result.CustomID, err)
+			it.Close()
+			return false
+		}
+	}
+	it.current = result
+	return true
+}
+
+// Result returns the current result. Should only be called after a
+// successful Next().
+func (it *BatchResultIterator) Result() *BatchResult {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *BatchResultIterator) Err() error {
+	return it.err
+}
+
+// Close closes the underlying response body.
+func (it *BatchResultIterator) Close() error {
+	var err error
+	it.closeOnce.Do(func() { err = it.body.Close() })
+	return err
+}
@@ -0,0 +1,215 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newBatchesTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return New(
+		WithAPIKey("test-key"),
+		WithEndpoint(server.URL+"/v1/messages"),
+		WithSystemPrompt("Classify the text."),
+	)
+}
+
+func TestBatchesClient_Create(t *testing.T) {
+	client := newBatchesTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/messages/batches" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var body struct {
+			Requests []struct {
+				CustomID string         `json:"custom_id"`
+				Params   map[string]any `json:"params"`
+			} `json:"requests"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		if len(body.Requests) != 2 {
+			t.Errorf("Expected 2 requests, got %d", len(body.Requests))
+		} else {
+			params := body.Requests[0].Params
+			if body.Requests[0].CustomID != "req-1" || params["model"] != DefaultModel || params["system"] == nil {
+				t.Errorf("Unexpected request params: %v", body.Requests[0])
+			}
+		}
+		w.Write([]byte(`{"id":"msgbatch_1","type":"message_batch","processing_status":"in_progress","request_counts":{"processing":2}}`))
+	})
+
+	batch, err := client.Batches().Create(context.Background(), []*BatchRequest{
+		{CustomID: "req-1", Messages: Messages{NewUserTextMessage("I love it")}},
+		{CustomID: "req-2", Messages: Messages{NewUserTextMessage("I hate it")}},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if batch.ID != "msgbatch_1" || batch.RequestCounts.Processing != 2 || batch.IsEnded() {
+		t.Errorf("Unexpected batch: %+v", batch)
+	}
+}
+
+func TestBatchesClient_Create_Invalid(t *testing.T) {
+	client := New(WithAPIKey("test-key"))
+	ctx := context.Background()
+
+	if _, err := client.Batches().Create(ctx, nil); err == nil {
+		t.Error("Expected error for empty batch")
+	}
+	if _, err := client.Batches().Create(ctx, []*BatchRequest{{Messages: Messages{NewUserTextMessage("Hi")}}}); err == nil {
+		t.Error("Expected error for missing custom id")
+	}
+	if _, err := client.Batches().Create(ctx, []*BatchRequest{{CustomID: "req-1"}}); err == nil {
+		t.Error("Expected error for request without messages")
+	}
+}
+
+func TestBatchesClient_Manage(t *testing.T) {
+	var polls int
+	client := newBatchesTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/messages/batches/msgbatch_1":
+			polls++
+			status := "in_progress"
+			if polls > 1 {
+				status = "ended"
+			}
+			w.Write([]byte(`{"id":"msgbatch_1","processing_status":"` + status + `"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/messages/batches":
+			if r.URL.Query().Get("limit") != "10" {
+				t.Errorf("Unexpected query %q", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"data":[{"id":"msgbatch_1"}],"has_more":false}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/messages/batches/msgbatch_1/cancel":
+			w.Write([]byte(`{"id":"msgbatch_1","processing_status":"canceling"}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/v1/messages/batches/msgbatch_1":
+			w.Write([]byte(`{"id":"msgbatch_1","type":"message_batch_deleted"}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+	ctx := context.Background()
+	batches := client.Batches()
+
+	batch, err := batches.Wait(ctx, "msgbatch_1", time.Millisecond)
+	if err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+	if !batch.IsEnded() || polls != 2 {
+		t.Errorf("Expected batch to end after 2 polls, got %d polls", polls)
+	}
+
+	list, err := batches.List(ctx, &ListParams{Limit: 10})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list.Data) != 1 || list.HasMore {
+		t.Errorf("Unexpected list: %+v", list)
+	}
+
+	batch, err = batches.Cancel(ctx, "msgbatch_1")
+	if err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	if batch.ProcessingStatus != BatchProcessingStatusCanceling {
+		t.Errorf("Expected canceling status, got %q", batch.ProcessingStatus)
+	}
+
+	if err := batches.Delete(ctx, "msgbatch_1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+}
+
+func TestBatchesClient_Results(t *testing.T) {
+	client := newBatchesTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages/batches/msgbatch_1/results" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"custom_id":"req-1","result":{"type":"succeeded","message":{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"positive"}]}}}
+{"custom_id":"req-2","result":{"type":"errored","error":{"type":"error","error":{"type":"invalid_request_error","message":"Bad request"}}}}
+{"custom_id":"req-3","result":{"type":"expired"}}
+`))
+	})
+
+	results, err := client.Batches().Results(context.Background(), "msgbatch_1")
+	if err != nil {
+		t.Fatalf("Results failed: %v", err)
+	}
+	var collected []*BatchResult
+	for results.Next() {
+		collected = append(collected, results.Result())
+	}
+	if err := results.Err(); err != nil {
+		t.Fatalf("Results error: %v", err)
+	}
+	if len(collected) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(collected))
+	}
+
+	if collected[0].Type != BatchResultTypeSucceeded || collected[0].Response.Message().Text() != "positive" {
+		t.Errorf("Unexpected first result: %+v", collected[0])
+	}
+	if collected[1].Type != BatchResultTypeErrored || !errors.Is(collected[1].Error, ErrInvalidRequest) {
+		t.Errorf("Unexpected second result: %+v", collected[1])
+	}
+	if collected[1].Error.Message() != "Bad request" {
+		t.Errorf("Unexpected error message %q", collected[1].Error.Message())
+	}
+	if collected[2].Type != BatchResultTypeExpired || collected[2].Response != nil || collected[2].Error != nil {
+		t.Errorf("Unexpected third result: %+v", collected[2])
+	}
+}
+
+func TestBatchesClient_ResultsResponseFormat(t *testing.T) {
+	client := newBatchesTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"custom_id":"req-1","result":{"type":"succeeded","message":{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"tool_use","id":"toolu_1","name":"json_response","input":{"answer":42}}]}}}
+{"custom_id":"req-2","result":{"type":"succeeded","message":{"id":"msg_2","type":"message","role":"assistant","content":[{"type":"text","text":" is the answer."}]}}}
+`))
+	})
+	requests := []*BatchRequest{
+		{
+			CustomID: "req-1",
+			Messages: Messages{NewUserTextMessage("What is the answer?")},
+			Options:  []Option{WithResponseFormat(&ResponseFormat{Type: ResponseFormatTypeJSON})},
+		},
+		{
+			CustomID: "req-2",
+			Messages: Messages{NewUserTextMessage("What is the answer?")},
+			Options:  []Option{WithPrefill("42", "")},
+		},
+	}
+
+	results, err := client.Batches().Results(context.Background(), "msgbatch_1", requests...)
+	if err != nil {
+		t.Fatalf("Results failed: %v", err)
+	}
+	defer results.Close()
+	if !results.Next() {
+		t.Fatalf("Expected a result: %v", results.Err())
+	}
+	var output struct {
+		Answer int `json:"answer"`
+	}
+	if err := results.Result().Response.Message().DecodeInto(&output); err != nil {
+		t.Fatalf("DecodeInto failed: %v", err)
+	}
+	if output.Answer != 42 {
+		t.Errorf("Expected answer 42, got %d", output.Answer)
+	}
+
+	if !results.Next() {
+		t.Fatalf("Expected a result: %v", results.Err())
+	}
+	if text := results.Result().Response.Message().Text(); text != "42 is the answer." {
+		t.Errorf("Expected prefilled text, got %q", text)
+	}
+}
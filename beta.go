@@ -0,0 +1,156 @@
+package anthropic
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// BetaFeature is a beta feature of the Anthropic API. Beta features are
+// enabled by listing them in the anthropic-beta request header. Learn more:
+// https://docs.anthropic.com/en/api/beta-headers
+type BetaFeature string
+
+func (b BetaFeature) String() string {
+	return string(b)
+}
+
+const (
+	BetaFilesAPI                 BetaFeature = "files-api-2025-04-14"
+	BetaMCPClient                BetaFeature = "mcp-client-2025-04-04"
+	BetaCodeExecution            BetaFeature = "code-execution-2025-05-22"
+	BetaInterleavedThinking      BetaFeature = "interleaved-thinking-2025-05-14"
+	BetaFineGrainedToolStreaming BetaFeature = "fine-grained-tool-streaming-2025-05-14"
+	BetaContext1M                BetaFeature = "context-1m-2025-08-07"
+	BetaExtendedCacheTTL         BetaFeature = "extended-cache-ttl-2025-04-11"
+	BetaTokenEfficientTools      BetaFeature = "token-efficient-tools-2025-02-19"
+	BetaOutput128K               BetaFeature = "output-128k-2025-02-19"
+)
+
+// betaHeader is the header used to enable beta features.
+const betaHeader = "anthropic-beta"
+
+// BetaFeatureProvider is implemented by tools that require beta features.
+// The features are enabled automatically on requests that include the tool.
+type BetaFeatureProvider interface {
+	BetaFeatures() []BetaFeature
+}
+
+// WithBetaFeatures enables the given beta features on every request, in
+// addition to those enabled automatically for the tools, MCP servers and
+// content used in a request.
+func WithBetaFeatures(features ...BetaFeature) Option {
+	return func(p *Client) {
+		enabled := slices.Clone(p.Features)
+		for _, feature := range features {
+			enabled = append(enabled, string(feature))
+		}
+		p.Features = enabled
+	}
+}
+
+// betaFeatures returns the beta features required by the request, including
+// those enabled explicitly on the client.
+func (p *Client) betaFeatures(req *Request) []string {
+	features := slices.Clone(p.Features)
+	for _, tool := range p.Tools {
+		if provider, ok := tool.(BetaFeatureProvider); ok {
+			for _, feature := range provider.BetaFeatures() {
+				features = append(features, string(feature))
+			}
+		}
+	}
+	if len(req.MCPServers) > 0 {
+		features = append(features, string(BetaMCPClient))
+	}
+	if requestUsesCacheTTL(req, CacheTTL1Hour) {
+		features = append(features, string(BetaExtendedCacheTTL))
+	}
+	if requestUsesFiles(req) {
+		features = append(features, string(BetaFilesAPI))
+	}
+	return features
+}
+
+func requestUsesCacheTTL(req *Request, ttl CacheTTL) bool {
+	for _, tool := range req.Tools {
+		if cacheControl, ok := tool["cache_control"].(*CacheControl); ok && cacheControl.TTL == ttl {
+			return true
+		}
+	}
+	for _, block := range req.System {
+		if block.CacheControl != nil && block.CacheControl.TTL == ttl {
+			return true
+		}
+	}
+	for _, message := range req.Messages {
+		for _, content := range message.Content {
+			if cacheControl := cacheControlOf(content); cacheControl != nil && cacheControl.TTL == ttl {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// requestUsesFiles returns true if the request references files uploaded
+// with the Files API.
+func requestUsesFiles(req *Request) bool {
+	for _, message := range req.Messages {
+		for _, content := range message.Content {
+			var source *ContentSource
+			switch c := content.(type) {
+			case *ImageContent:
+				source = c.Source
+			case *DocumentContent:
+				source = c.Source
+			}
+			if source != nil && source.Type == ContentSourceTypeFile {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasBetaFeature returns true if the feature is enabled explicitly, either
+// with WithBetaFeatures or in the request headers.
+func (p *Client) hasBetaFeature(feature BetaFeature) bool {
+	if slices.Contains(p.Features, string(feature)) {
+		return true
+	}
+	for _, value := range p.RequestHeaders.Values(betaHeader) {
+		for _, enabled := range strings.Split(value, ",") {
+			if strings.TrimSpace(enabled) == string(feature) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mergeBetaHeader combines all anthropic-beta header values into a single
+// comma-separated value, with duplicates removed.
+func mergeBetaHeader(header http.Header) {
+	var features []string
+	for _, value := range header.Values(betaHeader) {
+		for _, feature := range strings.Split(value, ",") {
+			feature = strings.TrimSpace(feature)
+			if feature != "" && !slices.Contains(features, feature) {
+				features = append(features, feature)
+			}
+		}
+	}
+	if len(features) == 0 {
+		header.Del(betaHeader)
+		return
+	}
+	header.Set(betaHeader, strings.Join(features, ","))
+}
+
+// addBetaFeatures adds the given beta features to the header.
+func addBetaFeatures(header http.Header, features []string) {
+	for _, feature := range features {
+		header.Add(betaHeader, feature)
+	}
+}
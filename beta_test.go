@@ -0,0 +1,94 @@
+package anthropic
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMergeBetaHeader(t *testing.T) {
+	header := http.Header{}
+	header.Add("anthropic-beta", "files-api-2025-04-14")
+	header.Add("anthropic-beta", "context-1m-2025-08-07, files-api-2025-04-14")
+	header.Add("Anthropic-Beta", " ,mcp-client-2025-04-04")
+	mergeBetaHeader(header)
+
+	values := header.Values("anthropic-beta")
+	expected := "files-api-2025-04-14,context-1m-2025-08-07,mcp-client-2025-04-04"
+	if len(values) != 1 || values[0] != expected {
+		t.Errorf("Expected %q, got %q", expected, values)
+	}
+
+	header = http.Header{}
+	header.Set("anthropic-beta", " ")
+	mergeBetaHeader(header)
+	if _, ok := header["Anthropic-Beta"]; ok {
+		t.Error("Expected empty beta header to be removed")
+	}
+}
+
+func TestClient_BetaFeatures(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		messages Messages
+		expected string
+	}{
+		{
+			name:     "none",
+			messages: Messages{NewUserTextMessage("Hi")},
+			expected: "",
+		},
+		{
+			name: "code execution and explicit features",
+			opts: []Option{
+				WithBetaFeatures(BetaContext1M, BetaInterleavedThinking),
+				WithTools(NewCodeExecutionTool(CodeExecutionToolOptions{})),
+			},
+			messages: Messages{NewUserTextMessage("Hi")},
+			expected: "context-1m-2025-08-07,interleaved-thinking-2025-05-14,code-execution-2025-05-22",
+		},
+		{
+			name: "mcp servers and request headers",
+			opts: []Option{
+				func(c *Client) {
+					c.MCPServers = []MCPServerConfig{{Type: "url", URL: "https://example.com/sse", Name: "example"}}
+				},
+				WithHeader("anthropic-beta", "mcp-client-2025-04-04,token-efficient-tools-2025-02-19"),
+			},
+			messages: Messages{NewUserTextMessage("Hi")},
+			expected: "mcp-client-2025-04-04,token-efficient-tools-2025-02-19",
+		},
+		{
+			name: "files and extended cache ttl",
+			opts: []Option{WithCaching(true), WithCacheTTL(CacheTTL1Hour)},
+			messages: Messages{NewUserMessage(&DocumentContent{
+				Source: &ContentSource{Type: ContentSourceTypeFile, FileID: "file_123"},
+			})},
+			expected: "extended-cache-ttl-2025-04-11,files-api-2025-04-14",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var header string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if values := r.Header.Values("anthropic-beta"); len(values) > 1 {
+					t.Errorf("Expected a single beta header, got %q", values)
+				}
+				header = r.Header.Get("anthropic-beta")
+				w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"Hi"}]}`))
+			}))
+			defer server.Close()
+
+			client := New(append([]Option{WithAPIKey("test-key"), WithEndpoint(server.URL)}, tt.opts...)...)
+			if _, err := client.Generate(context.Background(), tt.messages); err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if header != tt.expected {
+				t.Errorf("Expected beta header %q, got %q", tt.expected, header)
+			}
+		})
+	}
+}
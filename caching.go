@@ -0,0 +1,163 @@
+package anthropic
+
+import "maps"
+
+// CacheTTL is the lifetime of a prompt cache entry.
+type CacheTTL string
+
+const (
+	CacheTTL5Minutes CacheTTL = "5m"
+	CacheTTL1Hour    CacheTTL = "1h"
+)
+
+func (t CacheTTL) String() string {
+	return string(t)
+}
+
+// MaxCacheBreakpoints is the maximum number of cache_control breakpoints the
+// Anthropic API accepts in a single request.
+const MaxCacheBreakpoints = 4
+
+// WithCaching enables or disables automatic prompt caching. When enabled,
+// cache breakpoints are placed on the last tool definition, the system prompt
+// and the last user message of each request. Learn more:
+// https://docs.anthropic.com/en/docs/build-with-claude/prompt-caching
+func WithCaching(enabled bool) Option {
+	return func(p *Client) {
+		p.Caching = &enabled
+	}
+}
+
+// WithCacheTTL sets the lifetime of the cache breakpoints placed by automatic
+// prompt caching. The API default of 5 minutes applies if this is not set.
+func WithCacheTTL(ttl CacheTTL) Option {
+	return func(p *Client) {
+		p.CacheTTL = ttl
+	}
+}
+
+// applyCaching places cache breakpoints on the request if automatic caching is
+// enabled. Breakpoints are added in prefix order (tools, system, messages) and
+// breakpoints set explicitly by the caller count towards the limit. Anything
+// shared with the caller is copied before it is modified.
+func (p *Client) applyCaching(req *Request) {
+	if p.Caching == nil || !*p.Caching {
+		return
+	}
+	if capabilities, ok := LookupModelCapabilities(p.model); ok && !capabilities.SupportsCaching {
+		return
+	}
+	remaining := MaxCacheBreakpoints - countCacheBreakpoints(req)
+	cacheControl := &CacheControl{Type: CacheControlTypeEphemeral, TTL: p.CacheTTL}
+
+	if remaining > 0 && len(req.Tools) > 0 {
+		last := len(req.Tools) - 1
+		if _, ok := req.Tools[last]["cache_control"]; !ok {
+			tool := maps.Clone(req.Tools[last])
+			tool["cache_control"] = cacheControl
+			req.Tools[last] = tool
+			remaining--
+		}
+	}
+
+	if remaining > 0 && len(req.System) > 0 {
+		last := len(req.System) - 1
+		if req.System[last].CacheControl == nil {
+			block := *req.System[last]
+			block.CacheControl = cacheControl
+			req.System = append(req.System[:last:last], &block)
+			remaining--
+		}
+	}
+
+	if remaining > 0 {
+		for i := len(req.Messages) - 1; i >= 0; i-- {
+			message := req.Messages[i]
+			if message.Role != User {
+				continue
+			}
+			for j := len(message.Content) - 1; j >= 0; j-- {
+				if cacheControlOf(message.Content[j]) != nil {
+					break
+				}
+				content, ok := withCacheControl(message.Content[j], cacheControl)
+				if !ok {
+					continue
+				}
+				copied := *message
+				copied.Content = append([]Content(nil), message.Content...)
+				copied.Content[j] = content
+				req.Messages[i] = &copied
+				break
+			}
+			break
+		}
+	}
+}
+
+// countCacheBreakpoints returns the number of cache breakpoints that are
+// already set on the request.
+func countCacheBreakpoints(req *Request) int {
+	var count int
+	for _, tool := range req.Tools {
+		if _, ok := tool["cache_control"]; ok {
+			count++
+		}
+	}
+	for _, block := range req.System {
+		if block.CacheControl != nil {
+			count++
+		}
+	}
+	for _, message := range req.Messages {
+		for _, content := range message.Content {
+			if cacheControlOf(content) != nil {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func cacheControlOf(content Content) *CacheControl {
+	switch c := content.(type) {
+	case *TextContent:
+		return c.CacheControl
+	case *RefusalContent:
+		return c.CacheControl
+	case *ImageContent:
+		return c.CacheControl
+	case *DocumentContent:
+		return c.CacheControl
+	case *ToolResultContent:
+		return c.CacheControl
+	}
+	return nil
+}
+
+// withCacheControl returns a copy of the content with the given cache control
+// set. Returns false if the content cannot be used as a cache breakpoint.
+func withCacheControl(content Content, cacheControl *CacheControl) (Content, bool) {
+	var copied CacheControlSetter
+	switch c := content.(type) {
+	case *TextContent:
+		if c.Text == "" {
+			return nil, false
+		}
+		block := *c
+		copied = &block
+	case *ImageContent:
+		block := *c
+		copied = &block
+	case *DocumentContent:
+		block := *c
+		copied = &block
+	case *ToolResultContent:
+		block := *c
+		copied = &block
+	default:
+		return nil, false
+	}
+	copied.SetCacheControl(cacheControl)
+	return copied.(Content), true
+}
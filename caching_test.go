@@ -0,0 +1,107 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestApplyCaching(t *testing.T) {
+	client := New(
+		WithCaching(true),
+		WithCacheTTL(CacheTTL1Hour),
+		WithSystemPrompt("You are a weather assistant."),
+		WithTools(NewToolDefinition().
+			WithName("get_weather").
+			WithSchema(&Schema{Type: "object"})),
+	)
+	image := &ImageContent{Source: &ContentSource{Type: ContentSourceTypeBase64, MediaType: "image/png", Data: "aGVsbG8="}}
+	messages := Messages{
+		NewUserTextMessage("Hello"),
+		NewAssistantTextMessage("Hi, how can I help?"),
+		{Role: User, Content: []Content{&TextContent{Text: "What is in this image?"}, image}},
+	}
+
+	req, err := client.buildRequest(messages)
+	if err != nil {
+		t.Fatalf("buildRequest failed: %v", err)
+	}
+	if countCacheBreakpoints(req) != 3 {
+		t.Fatalf("Expected 3 breakpoints, got %d", countCacheBreakpoints(req))
+	}
+	if req.Tools[0]["cache_control"] == nil {
+		t.Error("Expected cache control on the last tool")
+	}
+	if req.System[0].CacheControl == nil || req.System[0].CacheControl.TTL != CacheTTL1Hour {
+		t.Errorf("Expected 1h cache control on the system prompt, got %+v", req.System[0].CacheControl)
+	}
+	cached, ok := req.Messages[2].Content[1].(*ImageContent)
+	if !ok || cached.CacheControl == nil {
+		t.Error("Expected cache control on the last block of the last user message")
+	}
+	if image.CacheControl != nil || messages[2].Content[1] != image {
+		t.Error("Expected the caller's messages to be left unchanged")
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("Failed to marshal request: %v", err)
+	}
+	if !strings.Contains(string(body), `"system":[{"type":"text","text":"You are a weather assistant.","cache_control":{"type":"ephemeral","ttl":"1h"}}]`) {
+		t.Errorf("Unexpected system prompt in request: %s", body)
+	}
+}
+
+func TestApplyCaching_BreakpointLimit(t *testing.T) {
+	client := New(WithCaching(true), WithSystemPrompt("System"))
+	explicit := &CacheControl{Type: CacheControlTypeEphemeral}
+	messages := Messages{
+		{Role: User, Content: []Content{
+			&TextContent{Text: "one", CacheControl: explicit},
+			&TextContent{Text: "two", CacheControl: explicit},
+			&TextContent{Text: "three", CacheControl: explicit},
+		}},
+		NewAssistantTextMessage("OK"),
+		NewUserTextMessage("four"),
+	}
+
+	req, err := client.buildRequest(messages)
+	if err != nil {
+		t.Fatalf("buildRequest failed: %v", err)
+	}
+	if count := countCacheBreakpoints(req); count != MaxCacheBreakpoints {
+		t.Errorf("Expected %d breakpoints, got %d", MaxCacheBreakpoints, count)
+	}
+	if req.System[0].CacheControl == nil {
+		t.Error("Expected cache control on the system prompt")
+	}
+	if cacheControlOf(req.Messages[2].Content[0]) != nil {
+		t.Error("Expected no cache control on the last user message once the limit is reached")
+	}
+}
+
+func TestApplyCaching_Disabled(t *testing.T) {
+	RegisterModelCapabilities("my-uncacheable-model", ModelCapabilities{MaxOutputTokens: 8192})
+	for _, client := range []*Client{
+		New(WithSystemPrompt("System")),
+		New(WithCaching(true), WithSystemPrompt("System"), WithModel("my-uncacheable-model")),
+	} {
+		req, err := client.buildRequest(Messages{NewUserTextMessage("Hello")})
+		if err != nil {
+			t.Fatalf("buildRequest failed: %v", err)
+		}
+		if count := countCacheBreakpoints(req); count != 0 {
+			t.Errorf("Expected no breakpoints for model %s, got %d", client.model, count)
+		}
+	}
+}
+
+func TestUsage_CacheHitRatio(t *testing.T) {
+	usage := &Usage{InputTokens: 50, CacheCreationInputTokens: 150, CacheReadInputTokens: 800}
+	if ratio := usage.CacheHitRatio(); ratio != 0.8 {
+		t.Errorf("Expected ratio 0.8, got %v", ratio)
+	}
+	if ratio := (&Usage{}).CacheHitRatio(); ratio != 0 {
+		t.Errorf("Expected ratio 0 for empty usage, got %v", ratio)
+	}
+}
@@ -50,6 +50,7 @@ func (c ContentSourceType) String() string {
 // CacheControl is used to control caching of content blocks.
 type CacheControl struct {
 	Type CacheControlType `json:"type"`
+	TTL  CacheTTL         `json:"ttl,omitempty"`
 }
 
 // ContentChunk is used within a Content block to pass chunks of content.
@@ -452,6 +453,59 @@ func (c *ToolResultContent) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// UnmarshalJSON handles content that is either a string or a list of content
+// blocks. Blocks are decoded into their concrete Content types, so the result
+// has the same form as the tool results produced by Client.Run.
+func (c *ToolResultContent) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		ToolUseID    string          `json:"tool_use_id"`
+		Content      json.RawMessage `json:"content"`
+		IsError      bool            `json:"is_error"`
+		CacheControl *CacheControl   `json:"cache_control"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	c.ToolUseID = raw.ToolUseID
+	c.IsError = raw.IsError
+	c.CacheControl = raw.CacheControl
+	c.Content = nil
+
+	content := bytes.TrimSpace(raw.Content)
+	if len(content) == 0 || bytes.Equal(content, []byte("null")) {
+		return nil
+	}
+	switch content[0] {
+	case '"':
+		var text string
+		if err := json.Unmarshal(content, &text); err != nil {
+			return err
+		}
+		c.Content = text
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(content, &items); err != nil {
+			return err
+		}
+		blocks := make([]Content, 0, len(items))
+		for _, item := range items {
+			block, err := UnmarshalContent(item)
+			if err != nil {
+				return err
+			}
+			blocks = append(blocks, block)
+		}
+		c.Content = blocks
+	default:
+		var value any
+		if err := json.Unmarshal(content, &value); err != nil {
+			return err
+		}
+		c.Content = value
+	}
+	return nil
+}
+
 func (c *ToolResultContent) SetCacheControl(cacheControl *CacheControl) {
 	c.CacheControl = cacheControl
 }
@@ -539,17 +593,61 @@ func (c *WebSearchToolResultContent) Type() ContentType {
 	return ContentTypeWebSearchToolResult
 }
 
+// webSearchToolResultError is the content of a web search tool result when
+// the search failed.
+type webSearchToolResultError struct {
+	Type      string `json:"type"` // "web_search_tool_result_error"
+	ErrorCode string `json:"error_code"`
+}
+
 func (c *WebSearchToolResultContent) MarshalJSON() ([]byte, error) {
-	type Alias WebSearchToolResultContent
+	var content any = c.Content
+	if c.ErrorCode != "" {
+		content = &webSearchToolResultError{
+			Type:      "web_search_tool_result_error",
+			ErrorCode: c.ErrorCode,
+		}
+	}
 	return json.Marshal(struct {
-		Type ContentType `json:"type"`
-		*Alias
+		Type      ContentType `json:"type"`
+		ToolUseID string      `json:"tool_use_id"`
+		Content   any         `json:"content"`
 	}{
-		Type:  ContentTypeWebSearchToolResult,
-		Alias: (*Alias)(c),
+		Type:      ContentTypeWebSearchToolResult,
+		ToolUseID: c.ToolUseID,
+		Content:   content,
 	})
 }
 
+// UnmarshalJSON handles both the list of results and the error object forms
+// of the content field.
+func (c *WebSearchToolResultContent) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		ToolUseID string          `json:"tool_use_id"`
+		Content   json.RawMessage `json:"content"`
+		ErrorCode string          `json:"error_code"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	c.ToolUseID = raw.ToolUseID
+	c.Content = nil
+	c.ErrorCode = raw.ErrorCode
+	content := bytes.TrimSpace(raw.Content)
+	if len(content) > 0 && content[0] == '{' {
+		var resultError webSearchToolResultError
+		if err := json.Unmarshal(content, &resultError); err != nil {
+			return err
+		}
+		c.ErrorCode = resultError.ErrorCode
+		return nil
+	}
+	if len(content) > 0 {
+		return json.Unmarshal(content, &c.Content)
+	}
+	return nil
+}
+
 //// ThinkingContent ///////////////////////////////////////////////////////////
 
 // https://docs.anthropic.com/en/docs/build-with-claude/extended-thinking
@@ -0,0 +1,49 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// TokenCount is the result of counting the tokens in a request.
+type TokenCount struct {
+	InputTokens int `json:"input_tokens"`
+}
+
+// CountTokens returns the number of input tokens the given messages would
+// use, including the system prompt, tools, MCP servers and thinking
+// configuration, exactly as they would be sent by Generate. Learn more:
+// https://docs.anthropic.com/en/docs/build-with-claude/token-counting
+func (p *Client) CountTokens(ctx context.Context, messages Messages, opts ...Option) (*TokenCount, error) {
+	p = p.withOptions(opts)
+	request, err := p.buildRequest(messages)
+	if err != nil {
+		return nil, err
+	}
+	// The token counting endpoint rejects parameters that only affect
+	// generation
+	request.MaxTokens = nil
+	request.Temperature = nil
+	request.TopP = nil
+	request.TopK = nil
+	request.StopSequences = nil
+	request.Metadata = nil
+	request.ServiceTier = ""
+	request.Stream = false
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request: %w", err)
+	}
+	header := http.Header{}
+	header.Set("content-type", "application/json")
+	addBetaFeatures(header, p.betaFeatures(request))
+
+	var result TokenCount
+	if err := p.doJSONRequest(ctx, http.MethodPost, p.apiURL("/messages/count_tokens"), body, header, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
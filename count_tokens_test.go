@@ -0,0 +1,53 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_CountTokens(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/messages/count_tokens" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		w.Write([]byte(`{"input_tokens":2095}`))
+	}))
+	defer server.Close()
+
+	client := New(
+		WithAPIKey("test-key"),
+		WithEndpoint(server.URL+"/v1/messages"),
+		WithMaxTokens(8192),
+		WithSystemPrompt("You are a scientist."),
+		WithTools(ToolAdapter(&weatherTool{})),
+		WithReasoningBudget(2048),
+		WithStopSequences("END"),
+		WithUserID("user-123"),
+	)
+
+	count, err := client.CountTokens(context.Background(), Messages{NewUserTextMessage("Hello")})
+	if err != nil {
+		t.Fatalf("CountTokens failed: %v", err)
+	}
+	if count.InputTokens != 2095 {
+		t.Errorf("Expected 2095 input tokens, got %d", count.InputTokens)
+	}
+
+	for _, key := range []string{"model", "messages", "system", "tools", "thinking"} {
+		if _, ok := body[key]; !ok {
+			t.Errorf("Expected %q to be sent", key)
+		}
+	}
+	for _, key := range []string{"max_tokens", "temperature", "stop_sequences", "metadata", "stream"} {
+		if _, ok := body[key]; ok {
+			t.Errorf("Expected %q to be omitted", key)
+		}
+	}
+}
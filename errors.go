@@ -0,0 +1,125 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// StatusOverloaded is the non-standard HTTP status code returned by the
+// Anthropic API when it is temporarily overloaded.
+const StatusOverloaded = 529
+
+// ErrorType identifies the kind of error returned by the Anthropic API. See:
+// https://docs.anthropic.com/en/api/errors
+type ErrorType string
+
+const (
+	ErrorTypeInvalidRequest  ErrorType = "invalid_request_error"
+	ErrorTypeAuthentication  ErrorType = "authentication_error"
+	ErrorTypePermission      ErrorType = "permission_error"
+	ErrorTypeNotFound        ErrorType = "not_found_error"
+	ErrorTypeRequestTooLarge ErrorType = "request_too_large"
+	ErrorTypeRateLimit       ErrorType = "rate_limit_error"
+	ErrorTypeAPI             ErrorType = "api_error"
+	ErrorTypeOverloaded      ErrorType = "overloaded_error"
+)
+
+func (e ErrorType) String() string {
+	return string(e)
+}
+
+// Sentinel errors that a ClientError matches with errors.Is, based on its
+// error type or status code.
+var (
+	ErrInvalidRequest  = errors.New("invalid request")
+	ErrAuthentication  = errors.New("authentication failed")
+	ErrPermission      = errors.New("permission denied")
+	ErrNotFound        = errors.New("not found")
+	ErrRequestTooLarge = errors.New("request too large")
+	ErrRateLimited     = errors.New("rate limited")
+	ErrOverloaded      = errors.New("overloaded")
+)
+
+var errorTypeSentinels = map[ErrorType]error{
+	ErrorTypeInvalidRequest:  ErrInvalidRequest,
+	ErrorTypeAuthentication:  ErrAuthentication,
+	ErrorTypePermission:      ErrPermission,
+	ErrorTypeNotFound:        ErrNotFound,
+	ErrorTypeRequestTooLarge: ErrRequestTooLarge,
+	ErrorTypeRateLimit:       ErrRateLimited,
+	ErrorTypeOverloaded:      ErrOverloaded,
+}
+
+var statusCodeSentinels = map[int]error{
+	http.StatusBadRequest:            ErrInvalidRequest,
+	http.StatusUnauthorized:          ErrAuthentication,
+	http.StatusForbidden:             ErrPermission,
+	http.StatusNotFound:              ErrNotFound,
+	http.StatusRequestEntityTooLarge: ErrRequestTooLarge,
+	http.StatusTooManyRequests:       ErrRateLimited,
+	StatusOverloaded:                 ErrOverloaded,
+}
+
+var errorTypeStatusCodes = map[ErrorType]int{
+	ErrorTypeInvalidRequest:  http.StatusBadRequest,
+	ErrorTypeAuthentication:  http.StatusUnauthorized,
+	ErrorTypePermission:      http.StatusForbidden,
+	ErrorTypeNotFound:        http.StatusNotFound,
+	ErrorTypeRequestTooLarge: http.StatusRequestEntityTooLarge,
+	ErrorTypeRateLimit:       http.StatusTooManyRequests,
+	ErrorTypeAPI:             http.StatusInternalServerError,
+	ErrorTypeOverloaded:      StatusOverloaded,
+}
+
+// APIError is the error object returned by the Anthropic API.
+type APIError struct {
+	Type    ErrorType `json:"type"`
+	Message string    `json:"message"`
+}
+
+/* Example:
+{
+  "type": "error",
+  "error": {
+    "type": "not_found_error",
+    "message": "The requested resource could not be found."
+  }
+}
+*/
+
+type apiErrorEnvelope struct {
+	Type  string    `json:"type"`
+	Error *APIError `json:"error"`
+}
+
+// parseAPIError extracts the error object from an API error response body.
+// Returns nil if the body is not an error envelope.
+func parseAPIError(body string) *APIError {
+	var envelope apiErrorEnvelope
+	if err := json.Unmarshal([]byte(body), &envelope); err != nil {
+		return nil
+	}
+	if envelope.Type != "error" || envelope.Error == nil {
+		return nil
+	}
+	return envelope.Error
+}
+
+// newAPIError creates a ClientError from an API error object that was not
+// accompanied by an HTTP status, such as an error event received while
+// streaming. The status code is inferred from the error type. The header is
+// that of the HTTP response the error was received in, if any.
+func newAPIError(apiError *APIError, header http.Header) *ClientError {
+	statusCode, ok := errorTypeStatusCodes[apiError.Type]
+	if !ok {
+		statusCode = http.StatusInternalServerError
+	}
+	body, _ := json.Marshal(apiErrorEnvelope{Type: "error", Error: apiError})
+	return &ClientError{
+		statusCode: statusCode,
+		body:       string(body),
+		header:     header,
+		apiError:   apiError,
+	}
+}
@@ -0,0 +1,83 @@
+package anthropic
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestNewError_ParsesAPIError(t *testing.T) {
+	header := http.Header{}
+	header.Set("request-id", "req_123")
+	body := `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`
+	err := NewErrorWithHeader(529, body, header)
+
+	if err.ErrorType() != ErrorTypeOverloaded {
+		t.Errorf("Expected error type %q, got %q", ErrorTypeOverloaded, err.ErrorType())
+	}
+	if err.Message() != "Overloaded" {
+		t.Errorf("Expected message 'Overloaded', got %q", err.Message())
+	}
+	if err.RequestID() != "req_123" {
+		t.Errorf("Expected request ID 'req_123', got %q", err.RequestID())
+	}
+	if err.Body() != body {
+		t.Errorf("Expected raw body to be preserved, got %q", err.Body())
+	}
+	expected := "provider api error (status 529, overloaded_error): Overloaded"
+	if err.Error() != expected {
+		t.Errorf("Error() = %q, expected %q", err.Error(), expected)
+	}
+	if !err.IsRecoverable() {
+		t.Error("Overloaded errors should be recoverable")
+	}
+}
+
+func TestNewError_NonJSONBody(t *testing.T) {
+	err := NewError(502, "Bad Gateway")
+
+	if err.ErrorType() != "" {
+		t.Errorf("Expected empty error type, got %q", err.ErrorType())
+	}
+	if err.Message() != "Bad Gateway" {
+		t.Errorf("Expected message to fall back to the body, got %q", err.Message())
+	}
+	if err.RequestID() != "" {
+		t.Errorf("Expected empty request ID, got %q", err.RequestID())
+	}
+}
+
+func TestClientError_Is(t *testing.T) {
+	envelope := func(errorType ErrorType) string {
+		return fmt.Sprintf(`{"type":"error","error":{"type":%q,"message":"test"}}`, errorType)
+	}
+	tests := []struct {
+		err      error
+		sentinel error
+	}{
+		{NewError(400, envelope(ErrorTypeInvalidRequest)), ErrInvalidRequest},
+		{NewError(401, envelope(ErrorTypeAuthentication)), ErrAuthentication},
+		{NewError(403, envelope(ErrorTypePermission)), ErrPermission},
+		{NewError(404, envelope(ErrorTypeNotFound)), ErrNotFound},
+		{NewError(413, envelope(ErrorTypeRequestTooLarge)), ErrRequestTooLarge},
+		{NewError(429, envelope(ErrorTypeRateLimit)), ErrRateLimited},
+		{NewError(529, envelope(ErrorTypeOverloaded)), ErrOverloaded},
+		{NewError(429, "Too Many Requests"), ErrRateLimited},
+		{NewError(529, ""), ErrOverloaded},
+		{fmt.Errorf("wrapped: %w", NewError(401, "")), ErrAuthentication},
+	}
+
+	for _, test := range tests {
+		if !errors.Is(test.err, test.sentinel) {
+			t.Errorf("Expected %v to match %v", test.err, test.sentinel)
+		}
+	}
+
+	if errors.Is(NewError(400, envelope(ErrorTypeInvalidRequest)), ErrRateLimited) {
+		t.Error("Invalid request error should not match ErrRateLimited")
+	}
+	if errors.Is(NewError(500, envelope(ErrorTypeAPI)), ErrOverloaded) {
+		t.Error("API error should not match ErrOverloaded")
+	}
+}
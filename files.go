@@ -0,0 +1,229 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/tectiv3/anthropic-go/retry"
+)
+
+/* Example:
+{
+  "id": "file_011CNha8iCJcU1wXNR6q4V8w",
+  "type": "file",
+  "filename": "document.pdf",
+  "mime_type": "application/pdf",
+  "size_bytes": 1024000,
+  "created_at": "2025-01-01T00:00:00Z",
+  "downloadable": false
+}
+*/
+
+// File is the metadata of a file uploaded via the Files API.
+type File struct {
+	ID           string    `json:"id"`
+	Type         string    `json:"type"`
+	Filename     string    `json:"filename"`
+	MimeType     string    `json:"mime_type"`
+	SizeBytes    int64     `json:"size_bytes"`
+	CreatedAt    time.Time `json:"created_at"`
+	Downloadable bool      `json:"downloadable"`
+}
+
+// FileList is one page of files returned by FilesClient.List.
+type FileList struct {
+	Data    []*File `json:"data"`
+	FirstID string  `json:"first_id"`
+	LastID  string  `json:"last_id"`
+	HasMore bool    `json:"has_more"`
+}
+
+// FilesClient is used to manage files with the Files API. Uploaded files may
+// be referenced in messages using the FileID content source. Learn more:
+// https://docs.anthropic.com/en/docs/build-with-claude/files
+type FilesClient struct {
+	client *Client
+}
+
+// Files returns a client for the Files API.
+func (p *Client) Files() *FilesClient {
+	return &FilesClient{client: p}
+}
+
+func (f *FilesClient) header() http.Header {
+	header := http.Header{}
+	addBetaFeatures(header, []string{string(BetaFilesAPI)})
+	return header
+}
+
+// Upload uploads the content read from r as a file with the given name. The
+// MIME type is derived from the file extension, or detected from the content
+// if the extension is not recognized.
+//
+// The content is streamed rather than held in memory. If r is an io.Seeker,
+// such as an *os.File, failed uploads are retried from the current offset.
+// Otherwise the upload is attempted only once.
+func (f *FilesClient) Upload(ctx context.Context, filename string, r io.Reader) (*File, error) {
+	body := &multipartFileBody{filename: filename, content: r}
+	if seeker, ok := r.(io.Seeker); ok {
+		start, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, fmt.Errorf("error reading file content: %w", err)
+		}
+		body.seeker, body.start = seeker, start
+	}
+
+	body.mimeType = mime.TypeByExtension(filepath.Ext(filename))
+	if body.mimeType == "" {
+		// Only the first 512 bytes are used to detect the content type
+		head := make([]byte, 512)
+		n, err := io.ReadFull(r, head)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("error reading file content: %w", err)
+		}
+		body.mimeType = http.DetectContentType(head[:n])
+		if body.seeker != nil {
+			if _, err := body.seeker.Seek(body.start, io.SeekStart); err != nil {
+				return nil, fmt.Errorf("error reading file content: %w", err)
+			}
+		} else {
+			body.content = io.MultiReader(bytes.NewReader(head[:n]), r)
+		}
+	}
+
+	writer := multipart.NewWriter(io.Discard)
+	body.boundary = writer.Boundary()
+	header := f.header()
+	header.Set("content-type", writer.FormDataContentType())
+
+	opts := f.client.retryOptions(ctx)
+	if body.seeker == nil {
+		opts = append(opts, retry.WithMaxRetries(0))
+	}
+	resp, err := f.client.sendAPIRequest(ctx, func() (*http.Request, error) {
+		req, err := f.client.newAPIRequest(ctx, http.MethodPost, f.client.apiURL("/files"), nil, header)
+		if err != nil {
+			return nil, err
+		}
+		if req.Body, err = body.open(); err != nil {
+			return nil, err
+		}
+		if body.seeker != nil {
+			req.GetBody = body.open
+		}
+		return req, nil
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var file File
+	if err := decodeJSONResponse(resp, &file); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// multipartFileBody streams a multipart form containing a file. The body may
+// be opened again if the content is seekable.
+type multipartFileBody struct {
+	filename string
+	mimeType string
+	boundary string
+	content  io.Reader
+	seeker   io.Seeker
+	start    int64
+	done     chan struct{}
+}
+
+// open returns a new reader for the body. The form is written to it from a
+// separate goroutine as it is read.
+func (b *multipartFileBody) open() (io.ReadCloser, error) {
+	if b.done != nil {
+		// Wait until the previous body has stopped reading the content. The
+		// HTTP client closes the previous body, which stops it.
+		<-b.done
+		if b.seeker == nil {
+			return nil, fmt.Errorf("file content cannot be read again")
+		}
+		if _, err := b.seeker.Seek(b.start, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("error reading file content: %w", err)
+		}
+	}
+	done := make(chan struct{})
+	b.done = done
+	reader, writer := io.Pipe()
+	go func() {
+		defer close(done)
+		writer.CloseWithError(b.write(writer))
+	}()
+	return reader, nil
+}
+
+func (b *multipartFileBody) write(w io.Writer) error {
+	writer := multipart.NewWriter(w)
+	if err := writer.SetBoundary(b.boundary); err != nil {
+		return fmt.Errorf("error creating multipart body: %w", err)
+	}
+	partHeader := textproto.MIMEHeader{}
+	partHeader.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{
+		"name":     "file",
+		"filename": b.filename,
+	}))
+	partHeader.Set("Content-Type", b.mimeType)
+	part, err := writer.CreatePart(partHeader)
+	if err != nil {
+		return fmt.Errorf("error creating multipart body: %w", err)
+	}
+	if _, err := io.Copy(part, b.content); err != nil {
+		return fmt.Errorf("error reading file content: %w", err)
+	}
+	return writer.Close()
+}
+
+// List returns one page of uploaded files. The params may be nil.
+func (f *FilesClient) List(ctx context.Context, params *ListParams) (*FileList, error) {
+	endpoint := withListParams(f.client.apiURL("/files"), params)
+	var list FileList
+	if err := f.client.doJSONRequest(ctx, http.MethodGet, endpoint, nil, f.header(), &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// Get returns the metadata of the file with the given ID.
+func (f *FilesClient) Get(ctx context.Context, fileID string) (*File, error) {
+	var file File
+	endpoint := f.client.apiURL("/files/" + url.PathEscape(fileID))
+	if err := f.client.doJSONRequest(ctx, http.MethodGet, endpoint, nil, f.header(), &file); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// Download returns the content of the file with the given ID. Only files
+// created by the code execution tool are downloadable. The caller must close
+// the returned reader.
+func (f *FilesClient) Download(ctx context.Context, fileID string) (io.ReadCloser, error) {
+	endpoint := f.client.apiURL("/files/" + url.PathEscape(fileID) + "/content")
+	resp, err := f.client.doAPIRequest(ctx, http.MethodGet, endpoint, nil, f.header())
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete deletes the file with the given ID.
+func (f *FilesClient) Delete(ctx context.Context, fileID string) error {
+	endpoint := f.client.apiURL("/files/" + url.PathEscape(fileID))
+	return f.client.doJSONRequest(ctx, http.MethodDelete, endpoint, nil, f.header(), nil)
+}
@@ -0,0 +1,171 @@
+package anthropic
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newFilesTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("anthropic-beta") != BetaFilesAPI.String() {
+			t.Errorf("Expected beta header %q, got %q", BetaFilesAPI, r.Header.Get("anthropic-beta"))
+		}
+		if r.Header.Get("x-api-key") != "test-key" {
+			t.Errorf("Expected API key header to be set")
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return New(WithAPIKey("test-key"), WithEndpoint(server.URL+"/v1/messages"))
+}
+
+func TestFilesClient_Upload(t *testing.T) {
+	client := newFilesTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/files" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("Failed to read form file: %v", err)
+			return
+		}
+		content, _ := io.ReadAll(file)
+		if string(content) != "hello" {
+			t.Errorf("Expected content 'hello', got %q", content)
+		}
+		if header.Filename != "notes.txt" {
+			t.Errorf("Expected filename 'notes.txt', got %q", header.Filename)
+		}
+		if !strings.HasPrefix(header.Header.Get("Content-Type"), "text/plain") {
+			t.Errorf("Expected text/plain content type, got %q", header.Header.Get("Content-Type"))
+		}
+		w.Write([]byte(`{"id":"file_123","type":"file","filename":"notes.txt","mime_type":"text/plain","size_bytes":5,"created_at":"2025-01-01T00:00:00Z"}`))
+	})
+
+	file, err := client.Files().Upload(context.Background(), "notes.txt", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if file.ID != "file_123" || file.SizeBytes != 5 || file.CreatedAt.Year() != 2025 {
+		t.Errorf("Unexpected file: %+v", file)
+	}
+}
+
+func TestFilesClient_UploadRetry(t *testing.T) {
+	content := "%PDF-1.4 " + strings.Repeat("x", 1024)
+	tests := []struct {
+		name          string
+		reader        func() io.Reader
+		expectedCalls int
+	}{
+		{"seekable", func() io.Reader { return strings.NewReader(content) }, 2},
+		{"not seekable", func() io.Reader { return io.MultiReader(strings.NewReader(content)) }, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			client := newFilesTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				file, header, err := r.FormFile("file")
+				if err != nil {
+					t.Errorf("Failed to read form file: %v", err)
+					return
+				}
+				received, _ := io.ReadAll(file)
+				if string(received) != content {
+					t.Errorf("Expected %d bytes of content, got %d", len(content), len(received))
+				}
+				if header.Header.Get("Content-Type") != "application/pdf" {
+					t.Errorf("Expected detected content type, got %q", header.Header.Get("Content-Type"))
+				}
+				if calls == 1 {
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+				w.Write([]byte(`{"id":"file_123","type":"file","filename":"report","size_bytes":1033}`))
+			})
+			client.Apply(WithBaseWait(time.Millisecond))
+
+			_, err := client.Files().Upload(context.Background(), "report", tt.reader())
+			if calls != tt.expectedCalls {
+				t.Errorf("Expected %d attempts, got %d", tt.expectedCalls, calls)
+			}
+			if tt.expectedCalls == 1 && !errors.Is(err, ErrRateLimited) {
+				t.Errorf("Expected ErrRateLimited, got %v", err)
+			}
+			if tt.expectedCalls > 1 && err != nil {
+				t.Errorf("Upload failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestFilesClient_List(t *testing.T) {
+	client := newFilesTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/files" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.URL.Query().Get("after_id") != "file_1" || r.URL.Query().Get("limit") != "2" {
+			t.Errorf("Unexpected query %q", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"data":[{"id":"file_2"},{"id":"file_3"}],"first_id":"file_2","last_id":"file_3","has_more":true}`))
+	})
+
+	list, err := client.Files().List(context.Background(), &ListParams{AfterID: "file_1", Limit: 2})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list.Data) != 2 || !list.HasMore || list.LastID != "file_3" {
+		t.Errorf("Unexpected list: %+v", list)
+	}
+}
+
+func TestFilesClient_GetDownloadDelete(t *testing.T) {
+	client := newFilesTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/files/file_123":
+			w.Write([]byte(`{"id":"file_123","filename":"chart.png","downloadable":true}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/files/file_123/content":
+			w.Write([]byte("binary data"))
+		case r.Method == http.MethodDelete && r.URL.Path == "/v1/files/file_123":
+			w.Write([]byte(`{"id":"file_123","type":"file_deleted"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"type":"error","error":{"type":"not_found_error","message":"File not found"}}`))
+		}
+	})
+	ctx := context.Background()
+
+	file, err := client.Files().Get(ctx, "file_123")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if file.Filename != "chart.png" || !file.Downloadable {
+		t.Errorf("Unexpected file: %+v", file)
+	}
+
+	content, err := client.Files().Download(ctx, "file_123")
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	data, _ := io.ReadAll(content)
+	content.Close()
+	if string(data) != "binary data" {
+		t.Errorf("Unexpected content %q", data)
+	}
+
+	if err := client.Files().Delete(ctx, "file_123"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	if _, err := client.Files().Get(ctx, "file_missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package anthropic
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// discardLogger is used when no logger is configured.
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// WithLogger sets the logger used to record requests, retries and the usage
+// of completed responses. Nothing is logged by default. API keys are never
+// included in log records.
+func WithLogger(logger *slog.Logger) Option {
+	return func(p *Client) {
+		p.logger = logger
+	}
+}
+
+// log returns the client's logger, or a logger that discards all records.
+func (p *Client) log() *slog.Logger {
+	if p.logger == nil {
+		return discardLogger
+	}
+	return p.logger
+}
+
+// logRequest records the start of an API request.
+func (p *Client) logRequest(ctx context.Context, req *http.Request) {
+	p.log().DebugContext(ctx, "anthropic request",
+		slog.String("method", req.Method),
+		slog.String("url", req.URL.Redacted()),
+		slog.String("model", p.model),
+		slog.String("beta", req.Header.Get(betaHeader)))
+}
+
+// logRetry records that a failed request will be retried.
+func (p *Client) logRetry(ctx context.Context, attempt int, wait time.Duration, err error) {
+	attrs := []any{
+		slog.Int("attempt", attempt),
+		slog.Duration("wait", wait),
+	}
+	var clientErr *ClientError
+	if errors.As(err, &clientErr) {
+		attrs = append(attrs,
+			slog.Int("status", clientErr.StatusCode()),
+			slog.String("request_id", clientErr.RequestID()))
+	}
+	attrs = append(attrs, slog.String("error", p.redact(err.Error())))
+	p.log().WarnContext(ctx, "anthropic request failed, retrying", attrs...)
+}
+
+// logResponse records a completed response and its token usage.
+func logResponse(ctx context.Context, logger *slog.Logger, msg string, resp *Response) {
+	logger.InfoContext(ctx, msg,
+		slog.String("id", resp.ID),
+		slog.String("model", resp.Model),
+		slog.String("stop_reason", resp.StopReason),
+		slog.Group("usage",
+			slog.Int("input_tokens", resp.Usage.InputTokens),
+			slog.Int("output_tokens", resp.Usage.OutputTokens),
+			slog.Int("cache_creation_input_tokens", resp.Usage.CacheCreationInputTokens),
+			slog.Int("cache_read_input_tokens", resp.Usage.CacheReadInputTokens)))
+}
+
+// redact replaces the API key in s, in case it is echoed back in an error.
+func (p *Client) redact(s string) string {
+	if p.apiKey == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, p.apiKey, "[REDACTED]")
+}
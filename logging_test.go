@@ -0,0 +1,139 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// logRecords decodes the records written by a JSON slog handler.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Failed to decode log record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestClient_Logger(t *testing.T) {
+	const apiKey = "sk-ant-secret-key"
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("request-id", "req_123")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"type":"error","error":{"type":"rate_limit_error","message":"Rate limited for key ` + apiKey + `"}}`))
+			return
+		}
+		w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-20250514","stop_reason":"end_turn","content":[{"type":"text","text":"Hi"}],"usage":{"input_tokens":10,"output_tokens":5}}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := New(WithAPIKey(apiKey), WithEndpoint(server.URL), WithBaseWait(time.Millisecond), WithLogger(logger))
+	if _, err := client.Generate(context.Background(), Messages{NewUserTextMessage("Hi")}); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if strings.Contains(buf.String(), apiKey) {
+		t.Errorf("Expected the API key to be redacted, got %s", buf.String())
+	}
+	records := logRecords(t, &buf)
+	var messages []string
+	for _, record := range records {
+		messages = append(messages, record["msg"].(string))
+	}
+	expected := []string{"anthropic request", "anthropic request failed, retrying", "anthropic request", "anthropic response"}
+	if strings.Join(messages, "|") != strings.Join(expected, "|") {
+		t.Fatalf("Expected records %q, got %q", expected, messages)
+	}
+
+	retry := records[1]
+	if retry["level"] != "WARN" || retry["attempt"] != float64(1) || retry["status"] != float64(429) || retry["request_id"] != "req_123" {
+		t.Errorf("Unexpected retry record: %v", retry)
+	}
+	if _, ok := retry["wait"]; !ok {
+		t.Errorf("Expected wait in retry record: %v", retry)
+	}
+	usage, ok := records[3]["usage"].(map[string]any)
+	if !ok || usage["input_tokens"] != float64(10) || usage["output_tokens"] != float64(5) {
+		t.Errorf("Unexpected usage in response record: %v", records[3])
+	}
+}
+
+func TestClient_LoggerStream(t *testing.T) {
+	server := newStreamServer(t, textAndToolStream)
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	client := New(WithAPIKey("test-key"), WithEndpoint(server.URL), WithLogger(logger))
+
+	if _, err := client.StreamToResponse(context.Background(), Messages{NewUserTextMessage("Hi")}, nil); err != nil {
+		t.Fatalf("StreamToResponse failed: %v", err)
+	}
+	records := logRecords(t, &buf)
+	if len(records) != 1 || records[0]["msg"] != "anthropic stream completed" {
+		t.Fatalf("Expected a stream completed record, got %v", records)
+	}
+	if records[0]["id"] != "msg_1" || records[0]["stop_reason"] != "tool_use" {
+		t.Errorf("Unexpected stream completed record: %v", records[0])
+	}
+	usage, ok := records[0]["usage"].(map[string]any)
+	if !ok || usage["output_tokens"] != float64(20) {
+		t.Errorf("Unexpected usage in stream completed record: %v", records[0])
+	}
+}
+
+func TestClient_NoLogger(t *testing.T) {
+	client := &Client{}
+	if client.log() == nil {
+		t.Fatal("Expected a logger for a client without one")
+	}
+	client = New(WithLogger(nil))
+	client.log().Info("discarded")
+}
+
+type traceIDKey struct{}
+
+// traceHandler adds the trace ID from the context to each record.
+type traceHandler struct {
+	slog.Handler
+}
+
+func (h traceHandler) Handle(ctx context.Context, record slog.Record) error {
+	if traceID, ok := ctx.Value(traceIDKey{}).(string); ok {
+		record.AddAttrs(slog.String("trace_id", traceID))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func TestClient_LoggerStreamContext(t *testing.T) {
+	server := newStreamServer(t, textAndToolStream)
+	var buf bytes.Buffer
+	logger := slog.New(traceHandler{slog.NewJSONHandler(&buf, nil)})
+	client := New(WithAPIKey("test-key"), WithEndpoint(server.URL), WithLogger(logger))
+
+	ctx := context.WithValue(context.Background(), traceIDKey{}, "trace_1")
+	if _, err := client.StreamToResponse(ctx, Messages{NewUserTextMessage("Hi")}, nil); err != nil {
+		t.Fatalf("StreamToResponse failed: %v", err)
+	}
+	records := logRecords(t, &buf)
+	if len(records) != 1 || records[0]["trace_id"] != "trace_1" {
+		t.Errorf("Expected the stream completed record to include the trace ID, got %v", records)
+	}
+}
@@ -30,6 +30,42 @@ type Message struct {
 	Content []Content `json:"content"`
 }
 
+// UnmarshalJSON decodes each content block into its concrete Content type, so
+// that messages encoded with json.Marshal can be decoded again. This allows
+// conversations to be saved and resumed later.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var tmp struct {
+		ID      string            `json:"id,omitempty"`
+		Role    Role              `json:"role"`
+		Content []json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+	content := make([]Content, 0, len(tmp.Content))
+	for i, rawContent := range tmp.Content {
+		block, err := UnmarshalContent(rawContent)
+		if err != nil {
+			return fmt.Errorf("invalid content block (index %d): %w", i, err)
+		}
+		content = append(content, block)
+	}
+	m.ID = tmp.ID
+	m.Role = tmp.Role
+	m.Content = content
+	return nil
+}
+
+// UnmarshalMessages decodes a conversation that was encoded with
+// json.Marshal, for example after being saved to disk.
+func UnmarshalMessages(data []byte) (Messages, error) {
+	var messages Messages
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
 // LastText returns the last text content in the message.
 func (m *Message) LastText() string {
 	for i := len(m.Content) - 1; i >= 0; i-- {
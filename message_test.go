@@ -1,6 +1,8 @@
 package anthropic
 
 import (
+	"encoding/json"
+	"reflect"
 	"testing"
 )
 
@@ -332,3 +334,72 @@ func TestMessage_DecodeInto_InvalidTarget(t *testing.T) {
 		t.Error("Expected error when decoding into non-pointer")
 	}
 }
+
+func TestMessages_JSONRoundTrip(t *testing.T) {
+	messages := Messages{
+		{Role: User, Content: []Content{
+			&TextContent{Text: "Describe this chart and the report.", CacheControl: &CacheControl{Type: CacheControlTypeEphemeral}},
+			&ImageContent{Source: &ContentSource{Type: ContentSourceTypeBase64, MediaType: "image/png", Data: "aGVsbG8="}},
+			&DocumentContent{Source: &ContentSource{Type: ContentSourceTypeFile, FileID: "file_123"}, Title: "Report"},
+		}},
+		{ID: "msg_1", Role: Assistant, Content: []Content{
+			&ThinkingContent{Thinking: "Let me look.", Signature: "EqQBCgIYAhIM"},
+			&RedactedThinkingContent{Data: "EmwKAhgBEgy3"},
+			&TextContent{Text: "Shannon was born in 1916.", Citations: []Citation{
+				&WebSearchResultLocation{Type: "web_search_result_location", URL: "https://example.com", Title: "Shannon"},
+			}},
+			&ServerToolUseContent{ID: "srvtoolu_1", Name: "web_search", Input: map[string]any{"query": "shannon"}},
+			&WebSearchToolResultContent{ToolUseID: "srvtoolu_1", Content: []*WebSearchResult{
+				{Type: "web_search_result", URL: "https://example.com", Title: "Shannon", EncryptedContent: "Eqgf"},
+			}},
+			&WebSearchToolResultContent{ToolUseID: "srvtoolu_2", ErrorCode: "max_uses_exceeded"},
+			&CodeExecutionToolResultContent{ToolUseID: "srvtoolu_3", Content: CodeExecutionResult{Type: "code_execution_result", Stdout: "42\n"}},
+			&MCPToolUseContent{ID: "mcptoolu_1", Name: "echo", ServerName: "example-mcp", Input: json.RawMessage(`{"text":"hi"}`)},
+			&MCPToolResultContent{ToolUseID: "mcptoolu_1", Content: []*ContentChunk{{Type: "text", Text: "hi"}}},
+			&ToolUseContent{ID: "toolu_1", Name: "get_weather", Input: json.RawMessage(`{"city":"Paris"}`)},
+			&ToolUseContent{ID: "toolu_2", Name: "get_chart", Input: json.RawMessage(`{}`)},
+		}},
+		{Role: User, Content: []Content{
+			&ToolResultContent{ToolUseID: "toolu_1", Content: "Sunny", IsError: false},
+			&ToolResultContent{ToolUseID: "toolu_2", Content: []Content{
+				&TextContent{Text: "Chart attached"},
+				&ImageContent{Source: &ContentSource{Type: ContentSourceTypeBase64, MediaType: "image/png", Data: "aGVsbG8="}},
+			}},
+		}},
+	}
+
+	data, err := json.Marshal(messages)
+	if err != nil {
+		t.Fatalf("Failed to marshal messages: %v", err)
+	}
+	decoded, err := UnmarshalMessages(data)
+	if err != nil {
+		t.Fatalf("UnmarshalMessages failed: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, messages) {
+		for i := range messages {
+			for j := range messages[i].Content {
+				if j < len(decoded[i].Content) && !reflect.DeepEqual(decoded[i].Content[j], messages[i].Content[j]) {
+					t.Errorf("Message %d block %d differs:\ngot:  %#v\nwant: %#v", i, j, decoded[i].Content[j], messages[i].Content[j])
+				}
+			}
+		}
+		t.Fatal("Decoded messages differ from the original messages")
+	}
+
+	again, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatalf("Failed to marshal decoded messages: %v", err)
+	}
+	if string(again) != string(data) {
+		t.Errorf("Round trip changed the encoding:\ngot:  %s\nwant: %s", again, data)
+	}
+}
+
+func TestMessage_UnmarshalJSON_Invalid(t *testing.T) {
+	var message Message
+	err := json.Unmarshal([]byte(`{"role":"user","content":[{"type":"unknown_block"}]}`), &message)
+	if err == nil {
+		t.Error("Expected error for unsupported content type")
+	}
+}
@@ -0,0 +1,153 @@
+package anthropic
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+/* Example:
+{
+  "type": "model",
+  "id": "claude-sonnet-4-20250514",
+  "display_name": "Claude Sonnet 4",
+  "created_at": "2025-05-22T00:00:00Z"
+}
+*/
+
+// ModelInfo describes a model available via the Anthropic API.
+type ModelInfo struct {
+	Type        string    `json:"type"`
+	ID          string    `json:"id"`
+	DisplayName string    `json:"display_name"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Capabilities returns the locally registered capabilities of the model, if
+// any. See LookupModelCapabilities.
+func (m *ModelInfo) Capabilities() (ModelCapabilities, bool) {
+	return LookupModelCapabilities(m.ID)
+}
+
+// ModelList is one page of models returned by Client.ListModels.
+type ModelList struct {
+	Data    []*ModelInfo `json:"data"`
+	FirstID string       `json:"first_id"`
+	LastID  string       `json:"last_id"`
+	HasMore bool         `json:"has_more"`
+}
+
+// ListModels returns one page of the models available to the API key, most
+// recently released first. The params may be nil.
+func (p *Client) ListModels(ctx context.Context, params *ListParams) (*ModelList, error) {
+	endpoint := withListParams(p.apiURL("/models"), params)
+	var list ModelList
+	if err := p.doJSONRequest(ctx, http.MethodGet, endpoint, nil, nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// GetModel returns information about the model with the given ID or alias.
+func (p *Client) GetModel(ctx context.Context, modelID string) (*ModelInfo, error) {
+	var model ModelInfo
+	endpoint := p.apiURL("/models/" + url.PathEscape(modelID))
+	if err := p.doJSONRequest(ctx, http.MethodGet, endpoint, nil, nil, &model); err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+// ModelCapabilities describes the limits and features of a model. These are
+// not reported by the Models API, so they are maintained in a local registry.
+type ModelCapabilities struct {
+	ContextWindow    int
+	MaxOutputTokens  int
+	SupportsThinking bool
+	SupportsVision   bool
+	SupportsPDF      bool
+	SupportsCaching  bool
+
+	// BetaMaxOutputTokens is the output limit when the BetaOutput128K
+	// feature is enabled, if the model supports it.
+	BetaMaxOutputTokens int
+}
+
+var (
+	modelCapabilitiesMutex sync.RWMutex
+
+	// modelCapabilities is keyed by model family, i.e. the model ID without
+	// any snapshot date or "-latest" suffix.
+	modelCapabilities = map[string]ModelCapabilities{
+		"claude-opus-4-1": {
+			ContextWindow: 200000, MaxOutputTokens: 32000,
+			SupportsThinking: true, SupportsVision: true, SupportsPDF: true, SupportsCaching: true,
+		},
+		"claude-opus-4": {
+			ContextWindow: 200000, MaxOutputTokens: 32000,
+			SupportsThinking: true, SupportsVision: true, SupportsPDF: true, SupportsCaching: true,
+		},
+		"claude-sonnet-4": {
+			ContextWindow: 200000, MaxOutputTokens: 64000,
+			SupportsThinking: true, SupportsVision: true, SupportsPDF: true, SupportsCaching: true,
+		},
+		"claude-3-7-sonnet": {
+			ContextWindow: 200000, MaxOutputTokens: 64000, BetaMaxOutputTokens: 128000,
+			SupportsThinking: true, SupportsVision: true, SupportsPDF: true, SupportsCaching: true,
+		},
+		"claude-3-5-sonnet": {
+			ContextWindow: 200000, MaxOutputTokens: 8192,
+			SupportsVision: true, SupportsPDF: true, SupportsCaching: true,
+		},
+		"claude-3-5-haiku": {
+			ContextWindow: 200000, MaxOutputTokens: 8192,
+			SupportsVision: true, SupportsPDF: true, SupportsCaching: true,
+		},
+		"claude-3-opus": {
+			ContextWindow: 200000, MaxOutputTokens: 4096,
+			SupportsVision: true, SupportsCaching: true,
+		},
+		"claude-3-haiku": {
+			ContextWindow: 200000, MaxOutputTokens: 4096,
+			SupportsVision: true, SupportsCaching: true,
+		},
+	}
+)
+
+var modelSnapshotSuffix = regexp.MustCompile(`-(\d{8}|latest|0)$`)
+
+// modelFamily returns the model ID without a snapshot date, "-latest" or
+// "-0" suffix, e.g. "claude-sonnet-4-20250514" becomes "claude-sonnet-4".
+func modelFamily(model string) string {
+	for {
+		trimmed := modelSnapshotSuffix.ReplaceAllString(model, "")
+		if trimmed == model {
+			return model
+		}
+		model = trimmed
+	}
+}
+
+// RegisterModelCapabilities adds or replaces the capabilities of a model in
+// the local registry. The model may be a specific ID or a model family.
+func RegisterModelCapabilities(model string, capabilities ModelCapabilities) {
+	modelCapabilitiesMutex.Lock()
+	defer modelCapabilitiesMutex.Unlock()
+	modelCapabilities[model] = capabilities
+}
+
+// LookupModelCapabilities returns the capabilities of the given model ID or
+// alias from the local registry.
+func LookupModelCapabilities(model string) (ModelCapabilities, bool) {
+	modelCapabilitiesMutex.RLock()
+	defer modelCapabilitiesMutex.RUnlock()
+	if capabilities, ok := modelCapabilities[model]; ok {
+		return capabilities, true
+	}
+	capabilities, ok := modelCapabilities[modelFamily(strings.ToLower(model))]
+	return capabilities, ok
+}
@@ -0,0 +1,156 @@
+package anthropic
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_ListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/models" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.URL.Query().Get("after_id") != "claude-opus-4-20250514" || r.URL.Query().Get("limit") != "1" {
+			t.Errorf("Unexpected query %q", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"data":[{"type":"model","id":"claude-sonnet-4-20250514","display_name":"Claude Sonnet 4","created_at":"2025-05-22T00:00:00Z"}],"first_id":"claude-sonnet-4-20250514","last_id":"claude-sonnet-4-20250514","has_more":true}`))
+	}))
+	defer server.Close()
+
+	client := New(WithAPIKey("test-key"), WithEndpoint(server.URL+"/v1/messages"))
+	list, err := client.ListModels(context.Background(), &ListParams{AfterID: "claude-opus-4-20250514", Limit: 1})
+	if err != nil {
+		t.Fatalf("ListModels failed: %v", err)
+	}
+	if len(list.Data) != 1 || !list.HasMore || list.LastID != "claude-sonnet-4-20250514" {
+		t.Fatalf("Unexpected list: %+v", list)
+	}
+	model := list.Data[0]
+	if model.DisplayName != "Claude Sonnet 4" || model.CreatedAt.Year() != 2025 {
+		t.Errorf("Unexpected model: %+v", model)
+	}
+	if capabilities, ok := model.Capabilities(); !ok || !capabilities.SupportsThinking {
+		t.Errorf("Expected thinking capability for %s", model.ID)
+	}
+}
+
+func TestClient_GetModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models/claude-3-5-haiku-latest" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"type":"error","error":{"type":"not_found_error","message":"model not found"}}`))
+			return
+		}
+		w.Write([]byte(`{"type":"model","id":"claude-3-5-haiku-20241022","display_name":"Claude Haiku 3.5","created_at":"2024-10-22T00:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	client := New(WithAPIKey("test-key"), WithEndpoint(server.URL+"/v1/messages"))
+	model, err := client.GetModel(context.Background(), "claude-3-5-haiku-latest")
+	if err != nil {
+		t.Fatalf("GetModel failed: %v", err)
+	}
+	if model.ID != "claude-3-5-haiku-20241022" {
+		t.Errorf("Unexpected model ID %q", model.ID)
+	}
+
+	if _, err := client.GetModel(context.Background(), "claude-unknown"); err == nil {
+		t.Error("Expected error for unknown model")
+	}
+}
+
+func TestLookupModelCapabilities(t *testing.T) {
+	tests := []struct {
+		model           string
+		found           bool
+		maxOutputTokens int
+		thinking        bool
+	}{
+		{"claude-sonnet-4-20250514", true, 64000, true},
+		{"claude-sonnet-4-0", true, 64000, true},
+		{"claude-opus-4-1-20250805", true, 32000, true},
+		{"claude-3-7-sonnet-latest", true, 64000, true},
+		{"claude-3-5-haiku-20241022", true, 8192, false},
+		{"claude-3-haiku-20240307", true, 4096, false},
+		{"my-custom-model", false, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			capabilities, ok := LookupModelCapabilities(tt.model)
+			if ok != tt.found {
+				t.Fatalf("Expected found=%v, got %v", tt.found, ok)
+			}
+			if capabilities.MaxOutputTokens != tt.maxOutputTokens || capabilities.SupportsThinking != tt.thinking {
+				t.Errorf("Unexpected capabilities: %+v", capabilities)
+			}
+		})
+	}
+
+	RegisterModelCapabilities("my-custom-model", ModelCapabilities{MaxOutputTokens: 1000})
+	if capabilities, ok := LookupModelCapabilities("my-custom-model-20250101"); !ok || capabilities.MaxOutputTokens != 1000 {
+		t.Errorf("Expected registered capabilities, got %+v", capabilities)
+	}
+}
+
+func TestApplyRequestConfig_ModelCapabilities(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr string
+	}{
+		{
+			name: "within limit",
+			opts: []Option{WithModel("claude-3-haiku-20240307"), WithMaxTokens(4096)},
+		},
+		{
+			name:    "max tokens above limit",
+			opts:    []Option{WithModel("claude-3-haiku-20240307"), WithMaxTokens(8192)},
+			wantErr: "exceeds the limit of 4096",
+		},
+		{
+			name:    "thinking unsupported",
+			opts:    []Option{WithModel("claude-3-5-haiku-latest"), WithMaxTokens(8000), WithReasoningBudget(2048)},
+			wantErr: "does not support reasoning",
+		},
+		{
+			name: "output 128k beta",
+			opts: []Option{WithModel("claude-3-7-sonnet-20250219"), WithMaxTokens(128000), WithBetaFeatures(BetaOutput128K)},
+		},
+		{
+			name: "output 128k beta in request headers",
+			opts: []Option{WithModel("claude-3-7-sonnet-latest"), WithMaxTokens(128000), WithHeader("anthropic-beta", string(BetaOutput128K))},
+		},
+		{
+			name:    "output 128k beta unsupported",
+			opts:    []Option{WithModel("claude-sonnet-4-20250514"), WithMaxTokens(128000), WithBetaFeatures(BetaOutput128K)},
+			wantErr: "exceeds the limit of 64000",
+		},
+		{
+			name:    "output 128k without beta",
+			opts:    []Option{WithModel("claude-3-7-sonnet-20250219"), WithMaxTokens(128000)},
+			wantErr: "exceeds the limit of 64000",
+		},
+		{
+			name: "unknown model is not validated",
+			opts: []Option{WithModel("claude-future"), WithMaxTokens(500000), WithReasoningBudget(2048)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := New(tt.opts...)
+			err := client.applyRequestConfig(&Request{})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,379 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ParsePartialJSON decodes JSON that may have been cut off part way through,
+// such as the tool input received so far while streaming. Open strings,
+// arrays and objects are closed and incomplete keys, numbers and literals are
+// dropped, so the result is the most complete value that the data represents.
+func ParsePartialJSON(data []byte, v any) error {
+	decoder := &partialJSONDecoder{useNumber: true}
+	decoder.Write(data)
+	value, ok := decoder.Value()
+	if !ok {
+		if decoder.err != nil {
+			return decoder.err
+		}
+		return errors.New("no complete json value found")
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, v)
+}
+
+// partialJSONExpect is the kind of token expected next by the decoder.
+type partialJSONExpect int
+
+const (
+	expectValue partialJSONExpect = iota
+	expectKey
+	expectColon
+	expectCommaOrClose
+	expectEnd
+)
+
+// partialJSONFrame is an open array or object. Completed values are added to
+// it and are not modified afterwards.
+type partialJSONFrame struct {
+	isArray bool
+	array   []any
+	object  map[string]any
+	key     string
+	hasKey  bool
+}
+
+// partialJSONDecoder decodes JSON incrementally as it is written, so that the
+// value received so far is available at any point without decoding the data
+// again from the start. Strings are built up as they arrive, and the value is
+// assembled from the open arrays and objects when it is requested.
+type partialJSONDecoder struct {
+	// useNumber decodes numbers as json.Number rather than float64
+	useNumber bool
+	// written is the number of bytes written so far
+	written int
+	err     error
+
+	stack      []*partialJSONFrame
+	expect     partialJSONExpect
+	allowClose bool // an array or object may be closed before its first value
+	root       any
+	hasRoot    bool
+
+	inString  bool
+	stringKey bool
+	str       strings.Builder
+	escape    []byte // an incomplete escape sequence
+	surrogate rune   // a high surrogate waiting for its low surrogate
+
+	inLiteral bool
+	literal   []byte // the number, true, false or null being decoded
+}
+
+// Write decodes the next part of the JSON data.
+func (d *partialJSONDecoder) Write(data []byte) {
+	d.written += len(data)
+	for i := 0; i < len(data) && d.err == nil; i++ {
+		c := data[i]
+		if d.inString {
+			if len(d.escape) > 0 || c == '\\' {
+				d.escape = append(d.escape, c)
+				d.decodeEscape()
+				continue
+			}
+			if c == '"' {
+				d.endString()
+				continue
+			}
+			// Copy the run of unescaped characters in one go
+			end := i + 1
+			for end < len(data) && data[end] != '"' && data[end] != '\\' {
+				end++
+			}
+			d.flushSurrogate()
+			d.str.Write(data[i:end])
+			i = end - 1
+			continue
+		}
+		if d.inLiteral {
+			if !isPartialJSONDelimiter(c) {
+				d.literal = append(d.literal, c)
+				continue
+			}
+			d.endLiteral()
+			if d.err != nil {
+				return
+			}
+		}
+		d.decodeByte(c)
+	}
+}
+
+func isPartialJSONDelimiter(c byte) bool {
+	return strings.IndexByte(",:]} \t\r\n\"[{", c) >= 0
+}
+
+// decodeByte handles a byte outside of any string or literal.
+func (d *partialJSONDecoder) decodeByte(c byte) {
+	switch c {
+	case ' ', '\t', '\r', '\n':
+		return
+	}
+	switch d.expect {
+	case expectValue:
+		switch {
+		case c == '{':
+			d.stack = append(d.stack, &partialJSONFrame{object: map[string]any{}})
+			d.expect, d.allowClose = expectKey, true
+		case c == '[':
+			d.stack = append(d.stack, &partialJSONFrame{isArray: true, array: []any{}})
+			d.expect, d.allowClose = expectValue, true
+		case c == ']' && d.allowClose:
+			d.closeFrame(true)
+		case c == '"':
+			d.inString, d.stringKey = true, false
+		case c == '-' || (c >= '0' && c <= '9') || c == 't' || c == 'f' || c == 'n':
+			d.inLiteral = true
+			d.literal = append(d.literal[:0], c)
+		default:
+			d.err = fmt.Errorf("invalid character %q in json value", c)
+		}
+	case expectKey:
+		switch {
+		case c == '"':
+			d.inString, d.stringKey = true, true
+		case c == '}' && d.allowClose:
+			d.closeFrame(false)
+		default:
+			d.err = fmt.Errorf("invalid character %q in json object", c)
+		}
+	case expectColon:
+		if c != ':' {
+			d.err = fmt.Errorf("invalid character %q after json object key", c)
+			return
+		}
+		d.expect, d.allowClose = expectValue, false
+	case expectCommaOrClose:
+		frame := d.stack[len(d.stack)-1]
+		switch {
+		case c == ',' && frame.isArray:
+			d.expect, d.allowClose = expectValue, false
+		case c == ',':
+			d.expect, d.allowClose = expectKey, false
+		case c == ']' && frame.isArray:
+			d.closeFrame(true)
+		case c == '}' && !frame.isArray:
+			d.closeFrame(false)
+		default:
+			d.err = fmt.Errorf("invalid character %q after json value", c)
+		}
+	case expectEnd:
+		d.err = fmt.Errorf("invalid character %q after top-level json value", c)
+	}
+}
+
+// closeFrame closes the innermost array or object and adds it to its parent.
+func (d *partialJSONDecoder) closeFrame(isArray bool) {
+	frame := d.stack[len(d.stack)-1]
+	if frame.isArray != isArray {
+		d.err = errors.New("mismatched json brackets")
+		return
+	}
+	d.stack = d.stack[:len(d.stack)-1]
+	if isArray {
+		d.addValue(frame.array)
+	} else {
+		d.addValue(frame.object)
+	}
+}
+
+// addValue adds a completed value to the innermost array or object.
+func (d *partialJSONDecoder) addValue(value any) {
+	if len(d.stack) == 0 {
+		d.root, d.hasRoot = value, true
+		d.expect = expectEnd
+		return
+	}
+	frame := d.stack[len(d.stack)-1]
+	if frame.isArray {
+		frame.array = append(frame.array, value)
+	} else {
+		frame.object[frame.key] = value
+		frame.hasKey = false
+	}
+	d.expect = expectCommaOrClose
+}
+
+// decodeEscape decodes the escape sequence once it is complete.
+func (d *partialJSONDecoder) decodeEscape() {
+	if len(d.escape) < 2 {
+		return
+	}
+	if d.escape[1] != 'u' {
+		var r byte
+		switch d.escape[1] {
+		case '"', '\\', '/':
+			r = d.escape[1]
+		case 'b':
+			r = '\b'
+		case 'f':
+			r = '\f'
+		case 'n':
+			r = '\n'
+		case 'r':
+			r = '\r'
+		case 't':
+			r = '\t'
+		default:
+			d.err = fmt.Errorf("invalid escape sequence %q in json string", d.escape)
+			return
+		}
+		d.flushSurrogate()
+		d.str.WriteByte(r)
+		d.escape = d.escape[:0]
+		return
+	}
+	if len(d.escape) < 6 {
+		return
+	}
+	code, err := strconv.ParseUint(string(d.escape[2:6]), 16, 16)
+	if err != nil {
+		d.err = fmt.Errorf("invalid escape sequence %q in json string", d.escape)
+		return
+	}
+	d.escape = d.escape[:0]
+	r := rune(code)
+	switch {
+	case d.surrogate != 0 && r >= 0xDC00 && r <= 0xDFFF:
+		d.str.WriteRune(utf16.DecodeRune(d.surrogate, r))
+		d.surrogate = 0
+	case r >= 0xD800 && r <= 0xDBFF:
+		d.flushSurrogate()
+		d.surrogate = r
+	default:
+		d.flushSurrogate()
+		// Lone low surrogates are written as the replacement character
+		d.str.WriteRune(r)
+	}
+}
+
+// flushSurrogate writes a high surrogate that was not followed by a low
+// surrogate as the replacement character, as encoding/json does.
+func (d *partialJSONDecoder) flushSurrogate() {
+	if d.surrogate != 0 {
+		d.str.WriteRune(utf8.RuneError)
+		d.surrogate = 0
+	}
+}
+
+func (d *partialJSONDecoder) endString() {
+	d.flushSurrogate()
+	s := d.str.String()
+	if !utf8.ValidString(s) {
+		s = strings.ToValidUTF8(s, string(utf8.RuneError))
+	}
+	d.str = strings.Builder{}
+	d.inString = false
+	if d.stringKey {
+		frame := d.stack[len(d.stack)-1]
+		frame.key, frame.hasKey = s, true
+		d.expect = expectColon
+		return
+	}
+	d.addValue(s)
+}
+
+func (d *partialJSONDecoder) endLiteral() {
+	d.inLiteral = false
+	value, ok := d.literalValue()
+	if !ok {
+		d.err = fmt.Errorf("invalid json literal %q", d.literal)
+		return
+	}
+	d.addValue(value)
+}
+
+// literalValue returns the value of the literal decoded so far, if it is
+// complete.
+func (d *partialJSONDecoder) literalValue() (any, bool) {
+	switch string(d.literal) {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	case "null":
+		return nil, true
+	}
+	if d.literal[0] != '-' && (d.literal[0] < '0' || d.literal[0] > '9') || !json.Valid(d.literal) {
+		return nil, false
+	}
+	if d.useNumber {
+		return json.Number(d.literal), true
+	}
+	f, err := strconv.ParseFloat(string(d.literal), 64)
+	return f, err == nil
+}
+
+// partialString returns the string value decoded so far, without any
+// incomplete escape sequence or UTF-8 character at the end.
+func (d *partialJSONDecoder) partialString() string {
+	s := d.str.String()
+	for i := len(s) - 1; i >= 0 && i >= len(s)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(s[i]) {
+			if !utf8.FullRuneInString(s[i:]) {
+				s = s[:i]
+			}
+			break
+		}
+	}
+	return s
+}
+
+// Value returns the value decoded so far, with open strings, arrays and
+// objects closed. Returns false if no value has been started or the data is
+// not valid JSON. Completed arrays and objects are shared between calls, so
+// the value must not be modified.
+func (d *partialJSONDecoder) Value() (any, bool) {
+	if d.err != nil {
+		return nil, false
+	}
+	if d.hasRoot {
+		return d.root, true
+	}
+	var value any
+	var ok bool
+	if d.inString && !d.stringKey {
+		value, ok = d.partialString(), true
+	} else if d.inLiteral {
+		value, ok = d.literalValue()
+	}
+	for i := len(d.stack) - 1; i >= 0; i-- {
+		frame := d.stack[i]
+		if frame.isArray {
+			array := make([]any, len(frame.array), len(frame.array)+1)
+			copy(array, frame.array)
+			if ok {
+				array = append(array, value)
+			}
+			value = array
+		} else {
+			object := maps.Clone(frame.object)
+			if ok && frame.hasKey {
+				object[frame.key] = value
+			}
+			value = object
+		}
+		ok = true
+	}
+	return value, ok
+}
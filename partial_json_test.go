@@ -0,0 +1,176 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePartialJSON(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+		wantErr  bool
+	}{
+		{``, nil, true},
+		{`{`, map[string]any{}, false},
+		{`{"pa`, map[string]any{}, false},
+		{`{"path"`, map[string]any{}, false},
+		{`{"path":`, map[string]any{}, false},
+		{`{"path": "main.go", "content": "package ma`, map[string]any{"path": "main.go", "content": "package ma"}, false},
+		{`{"content": "line one\nline two\`, map[string]any{"content": "line one\nline two"}, false},
+		{`{"content": "caf\u00`, map[string]any{"content": "caf"}, false},
+		{`{"content": "café`, map[string]any{"content": "café"}, false},
+		{`{"count": 12`, map[string]any{"count": 12.0}, false},
+		{`{"count": 12, "enabled": tr`, map[string]any{"count": 12.0}, false},
+		{`{"count": -`, map[string]any{}, false},
+		{`{"items": [1, 2, {"name": "x`, map[string]any{"items": []any{1.0, 2.0, map[string]any{"name": "x"}}}, false},
+		{`{"items": ["a", "b"], "nested": {"ok": true}}`, map[string]any{"items": []any{"a", "b"}, "nested": map[string]any{"ok": true}}, false},
+		{`{"items": [`, map[string]any{"items": []any{}}, false},
+		{`{"emoji": "\ud83d\ude00"}`, map[string]any{"emoji": "\U0001F600"}, false},
+		{`{"emoji": "\ud83d`, map[string]any{"emoji": ""}, false},
+		{`{"emoji": "\ud83d\u00e9"}`, map[string]any{"emoji": "\uFFFDé"}, false},
+		{`[1, "tw`, []any{1.0, "tw"}, false},
+		{`{"a": 1}}`, nil, true},
+		{`{"a" 1`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var result any
+			err := ParsePartialJSON([]byte(tt.input), &result)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got %v", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected %#v, got %#v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestStreamIterator_PartialToolInput(t *testing.T) {
+	server := newStreamServer(t, `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[]}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_1","name":"write_file","input":{}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"path\": \"main.go\", \"content\": \"package"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":" main\\n\"}"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`)
+
+	var previews []string
+	client := New(
+		WithAPIKey("test-key"),
+		WithEndpoint(server.URL),
+		WithPartialToolInputCallback(func(index int, name string, input map[string]any) {
+			if index != 0 || name != "write_file" {
+				t.Errorf("Unexpected callback for block %d %q", index, name)
+			}
+			content, _ := input["content"].(string)
+			previews = append(previews, content)
+		}),
+	)
+	stream, err := client.Stream(context.Background(), Messages{NewUserTextMessage("Write main.go")})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	for stream.Next() {
+		if stream.Event().Type == EventTypeContentBlockStart {
+			if input := stream.PartialToolInput(0); input != nil {
+				t.Errorf("Expected no input before any deltas, got %v", input)
+			}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{"package", "package main\n"}
+	if !reflect.DeepEqual(previews, expected) {
+		t.Errorf("Expected previews %q, got %q", expected, previews)
+	}
+	if input := stream.PartialToolInput(0); input["path"] != "main.go" {
+		t.Errorf("Unexpected final input: %v", input)
+	}
+	if input := stream.PartialToolInput(1); input != nil {
+		t.Errorf("Expected nil input for a missing block, got %v", input)
+	}
+}
+
+func TestPartialJSONDecoder_Incremental(t *testing.T) {
+	data := []byte(`{"path": "main.go", "count": -12.5e3, "ok": true, "none": null, ` +
+		`"items": [[], {}, ["a\"b"]], "text": "caf\u00e9 \ud83d\ude00 ☕\n"}`)
+
+	decoder := &partialJSONDecoder{}
+	for i := 1; i <= len(data); i++ {
+		decoder.Write(data[i-1 : i])
+		value, ok := decoder.Value()
+
+		var expected any
+		err := ParsePartialJSON(data[:i], &expected)
+		if ok != (err == nil) {
+			t.Fatalf("Prefix %q: decoder ok %v, ParsePartialJSON error %v", data[:i], ok, err)
+		}
+		if ok && !reflect.DeepEqual(value, expected) {
+			t.Fatalf("Prefix %q: expected %#v, got %#v", data[:i], expected, value)
+		}
+	}
+
+	var expected any
+	if err := json.Unmarshal(data, &expected); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := decoder.Value(); !reflect.DeepEqual(value, expected) {
+		t.Errorf("Expected %#v, got %#v", expected, value)
+	}
+}
+
+func BenchmarkResponseAccumulator_PartialToolInput(b *testing.B) {
+	input, err := json.Marshal(map[string]any{
+		"path":    "main.go",
+		"content": strings.Repeat("func main() {\n\tprintln(\"hello\")\n}\n", 4096),
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(input)))
+
+	for b.Loop() {
+		accumulator := NewResponseAccumulator()
+		index := 0
+		accumulator.AddEvent(&Event{Type: EventTypeMessageStart, Message: &Response{}})
+		accumulator.AddEvent(&Event{
+			Type:         EventTypeContentBlockStart,
+			Index:        &index,
+			ContentBlock: &EventContentBlock{Type: ContentTypeToolUse, ID: "toolu_1", Name: "write_file"},
+		})
+		for start := 0; start < len(input); start += 20 {
+			end := min(start+20, len(input))
+			accumulator.AddEvent(&Event{
+				Type:  EventTypeContentBlockDelta,
+				Index: &index,
+				Delta: &EventDelta{Type: EventDeltaTypeInputJSON, PartialJSON: string(input[start:end])},
+			})
+			accumulator.partialToolInput(index)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package anthropic
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit describes the state of one rate limit, as reported by the
+// anthropic-ratelimit-* response headers.
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// RateLimits contains the rate limits reported in an API response. See:
+// https://docs.anthropic.com/en/api/rate-limits#response-headers
+type RateLimits struct {
+	Requests     RateLimit
+	Tokens       RateLimit
+	InputTokens  RateLimit
+	OutputTokens RateLimit
+}
+
+// ParseRateLimits reads the anthropic-ratelimit-* headers. Returns nil if
+// none of the headers are present.
+func ParseRateLimits(header http.Header) *RateLimits {
+	var found bool
+	parse := func(name string) RateLimit {
+		prefix := "anthropic-ratelimit-" + name + "-"
+		var limit RateLimit
+		if value := header.Get(prefix + "limit"); value != "" {
+			limit.Limit, _ = strconv.Atoi(value)
+			found = true
+		}
+		if value := header.Get(prefix + "remaining"); value != "" {
+			limit.Remaining, _ = strconv.Atoi(value)
+			found = true
+		}
+		if value := header.Get(prefix + "reset"); value != "" {
+			limit.Reset, _ = time.Parse(time.RFC3339, value)
+			found = true
+		}
+		return limit
+	}
+	limits := &RateLimits{
+		Requests:     parse("requests"),
+		Tokens:       parse("tokens"),
+		InputTokens:  parse("input-tokens"),
+		OutputTokens: parse("output-tokens"),
+	}
+	if !found {
+		return nil
+	}
+	return limits
+}
+
+// parseRetryAfter returns the delay requested by the retry-after header,
+// which is given either in seconds or as an HTTP date.
+func parseRetryAfter(header http.Header, now time.Time) time.Duration {
+	value := strings.TrimSpace(header.Get("retry-after"))
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// exhaustedResetDelay returns how long until every exhausted rate limit has
+// been reset, or zero if no limits are exhausted.
+func (r *RateLimits) exhaustedResetDelay(now time.Time) time.Duration {
+	var delay time.Duration
+	for _, limit := range []RateLimit{r.Requests, r.Tokens, r.InputTokens, r.OutputTokens} {
+		if limit.Limit == 0 || limit.Remaining > 0 || limit.Reset.IsZero() {
+			continue
+		}
+		if d := limit.Reset.Sub(now); d > delay {
+			delay = d
+		}
+	}
+	return delay
+}
@@ -0,0 +1,102 @@
+package anthropic
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimits(t *testing.T) {
+	header := http.Header{}
+	header.Set("anthropic-ratelimit-requests-limit", "50")
+	header.Set("anthropic-ratelimit-requests-remaining", "0")
+	header.Set("anthropic-ratelimit-requests-reset", "2025-01-02T03:04:05Z")
+	header.Set("anthropic-ratelimit-input-tokens-limit", "40000")
+	header.Set("anthropic-ratelimit-input-tokens-remaining", "39000")
+
+	limits := ParseRateLimits(header)
+	if limits == nil {
+		t.Fatal("Expected rate limits to be parsed")
+	}
+	if limits.Requests.Limit != 50 || limits.Requests.Remaining != 0 {
+		t.Errorf("Unexpected request limits: %+v", limits.Requests)
+	}
+	expectedReset := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	if !limits.Requests.Reset.Equal(expectedReset) {
+		t.Errorf("Expected reset %v, got %v", expectedReset, limits.Requests.Reset)
+	}
+	if limits.InputTokens.Limit != 40000 || limits.InputTokens.Remaining != 39000 {
+		t.Errorf("Unexpected input token limits: %+v", limits.InputTokens)
+	}
+
+	if ParseRateLimits(http.Header{}) != nil {
+		t.Error("Expected nil rate limits when headers are absent")
+	}
+}
+
+func TestClientError_RetryAfter(t *testing.T) {
+	header := http.Header{}
+	header.Set("retry-after", "7")
+	err := NewErrorWithHeader(429, "rate limited", header)
+	if err.RetryAfter() != 7*time.Second {
+		t.Errorf("Expected 7s, got %v", err.RetryAfter())
+	}
+
+	header = http.Header{}
+	header.Set("retry-after", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	err = NewErrorWithHeader(429, "rate limited", header)
+	if wait := err.RetryAfter(); wait < 58*time.Second || wait > time.Minute {
+		t.Errorf("Expected about 1m, got %v", wait)
+	}
+
+	header = http.Header{}
+	header.Set("anthropic-ratelimit-tokens-limit", "1000")
+	header.Set("anthropic-ratelimit-tokens-remaining", "0")
+	header.Set("anthropic-ratelimit-tokens-reset", time.Now().Add(30*time.Second).UTC().Format(time.RFC3339))
+	err = NewErrorWithHeader(429, "rate limited", header)
+	if wait := err.RetryAfter(); wait < 28*time.Second || wait > 30*time.Second {
+		t.Errorf("Expected about 30s, got %v", wait)
+	}
+
+	if NewError(429, "rate limited").RetryAfter() != 0 {
+		t.Error("Expected no delay without headers")
+	}
+}
+
+func TestClient_Generate_RetryAfter(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("retry-after", "0.05")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`))
+			return
+		}
+		w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"Hi"}]}`))
+	}))
+	defer server.Close()
+
+	client := New(
+		WithAPIKey("test-key"),
+		WithEndpoint(server.URL),
+		WithBaseWait(time.Hour),
+	)
+
+	start := time.Now()
+	response, err := client.Generate(context.Background(), Messages{NewUserTextMessage("Hello")})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if response.Message().Text() != "Hi" {
+		t.Errorf("Unexpected response text %q", response.Message().Text())
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 calls, got %d", calls)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > 10*time.Second {
+		t.Errorf("Expected to wait for the retry-after delay, took %v", elapsed)
+	}
+}
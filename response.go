@@ -4,6 +4,16 @@ import (
 	"encoding/json"
 )
 
+// Stop reasons reported by the Anthropic API in Response.StopReason.
+const (
+	StopReasonEndTurn      = "end_turn"
+	StopReasonMaxTokens    = "max_tokens"
+	StopReasonStopSequence = "stop_sequence"
+	StopReasonToolUse      = "tool_use"
+	StopReasonPauseTurn    = "pause_turn"
+	StopReasonRefusal      = "refusal"
+)
+
 // Response is the generated response from an LLM. Matches the Anthropic
 // response format documented here:
 // https://docs.anthropic.com/en/api/messages#response-content
@@ -1,5 +1,7 @@
 package anthropic
 
+import "fmt"
+
 type ResponseFormatType string
 
 const (
@@ -22,3 +24,64 @@ type ResponseFormat struct {
 	// Description provides additional context to guide the model
 	Description string `json:"description,omitempty"`
 }
+
+// DefaultResponseFormatToolName is the name of the tool used to request
+// structured output when the ResponseFormat does not specify a name.
+var DefaultResponseFormatToolName = "json_response"
+
+// toolName returns the name of the tool used to produce structured output.
+func (f *ResponseFormat) toolName() string {
+	if f.Name != "" {
+		return f.Name
+	}
+	return DefaultResponseFormatToolName
+}
+
+// toolConfiguration returns the configuration of a tool whose input schema
+// matches the response format. The Anthropic API has no native structured
+// output mode, so the LLM is forced to call this tool and its input is used
+// as the response. Returns nil for plain text responses.
+func (f *ResponseFormat) toolConfiguration() (map[string]any, error) {
+	var schema any
+	switch f.Type {
+	case ResponseFormatTypeText, "":
+		return nil, nil
+	case ResponseFormatTypeJSON:
+		schema = f.Schema
+		if f.Schema == nil {
+			schema = map[string]any{"type": Object}
+		}
+	case ResponseFormatTypeJSONSchema:
+		if f.Schema == nil {
+			return nil, fmt.Errorf("response format %q requires a schema", f.Type)
+		}
+		schema = f.Schema
+	default:
+		return nil, fmt.Errorf("unsupported response format type: %q", f.Type)
+	}
+	description := f.Description
+	if description == "" {
+		description = "Respond with structured output matching the input schema."
+	}
+	return map[string]any{
+		"name":         f.toolName(),
+		"description":  description,
+		"input_schema": schema,
+	}, nil
+}
+
+// extractStructuredOutput replaces the forced tool call in the response with
+// a text block containing the tool input, so that the structured output can
+// be read with Message.DecodeInto. The stop reason is changed from tool_use
+// to end_turn if no other tool calls remain, since the model has finished.
+func (f *ResponseFormat) extractStructuredOutput(response *Response) {
+	name := f.toolName()
+	for i, content := range response.Content {
+		if toolUse, ok := content.(*ToolUseContent); ok && toolUse.Name == name {
+			response.Content[i] = &TextContent{Text: string(toolUse.Input)}
+		}
+	}
+	if response.StopReason == StopReasonToolUse && len(response.ToolCalls()) == 0 {
+		response.StopReason = StopReasonEndTurn
+	}
+}
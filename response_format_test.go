@@ -0,0 +1,165 @@
+package anthropic
+
+import (
+	"context"
+	"testing"
+)
+
+func TestApplyRequestConfig_ResponseFormat(t *testing.T) {
+	schema := &Schema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"answer": {Type: Integer},
+		},
+		Required: []string{"answer"},
+	}
+	client := New(WithResponseFormat(&ResponseFormat{
+		Type:   ResponseFormatTypeJSONSchema,
+		Name:   "answer",
+		Schema: schema,
+	}))
+
+	var request Request
+	if err := client.applyRequestConfig(&request); err != nil {
+		t.Fatalf("applyRequestConfig failed: %v", err)
+	}
+	if len(request.Tools) != 1 {
+		t.Fatalf("Expected 1 tool, got %d", len(request.Tools))
+	}
+	if request.Tools[0]["name"] != "answer" {
+		t.Errorf("Expected tool name 'answer', got %v", request.Tools[0]["name"])
+	}
+	if request.Tools[0]["input_schema"] != schema {
+		t.Error("Expected input schema to be the response format schema")
+	}
+	if request.ToolChoice == nil || request.ToolChoice.Type != ToolChoiceTypeTool || request.ToolChoice.Name != "answer" {
+		t.Errorf("Expected forced tool choice, got %+v", request.ToolChoice)
+	}
+}
+
+func TestApplyRequestConfig_ResponseFormatText(t *testing.T) {
+	client := New(WithResponseFormat(&ResponseFormat{Type: ResponseFormatTypeText}))
+
+	var request Request
+	if err := client.applyRequestConfig(&request); err != nil {
+		t.Fatalf("applyRequestConfig failed: %v", err)
+	}
+	if len(request.Tools) != 0 || request.ToolChoice != nil {
+		t.Error("Text response format should not add a tool")
+	}
+}
+
+func TestApplyRequestConfig_ResponseFormatMissingSchema(t *testing.T) {
+	client := New(WithResponseFormat(&ResponseFormat{Type: ResponseFormatTypeJSONSchema}))
+
+	var request Request
+	if err := client.applyRequestConfig(&request); err == nil {
+		t.Error("Expected error for JSON schema format without a schema")
+	}
+}
+
+func TestClient_Generate_ResponseFormat(t *testing.T) {
+	server := newMockServer(t, []string{
+		`{"id":"msg_1","type":"message","role":"assistant","stop_reason":"tool_use","content":[
+			{"type":"tool_use","id":"toolu_1","name":"json_response","input":{"answer":42}}
+		]}`,
+	}, nil)
+
+	client := New(
+		WithAPIKey("test-key"),
+		WithEndpoint(server.URL),
+		WithResponseFormat(&ResponseFormat{
+			Type: ResponseFormatTypeJSONSchema,
+			Schema: &Schema{
+				Type:       Object,
+				Properties: map[string]*Property{"answer": {Type: Integer}},
+			},
+		}),
+	)
+
+	response, err := client.Generate(context.Background(), Messages{NewUserTextMessage("What is the answer?")})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if len(response.ToolCalls()) != 0 {
+		t.Error("Structured output tool call should be removed from the response")
+	}
+	if response.StopReason != StopReasonEndTurn {
+		t.Errorf("Expected stop reason %q, got %q", StopReasonEndTurn, response.StopReason)
+	}
+
+	var output struct {
+		Answer int `json:"answer"`
+	}
+	if err := response.Message().DecodeInto(&output); err != nil {
+		t.Fatalf("DecodeInto failed: %v", err)
+	}
+	if output.Answer != 42 {
+		t.Errorf("Expected answer 42, got %d", output.Answer)
+	}
+}
+
+func TestClient_StreamToResponse_ResponseFormat(t *testing.T) {
+	server := newStreamServer(t, `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[]}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_1","name":"json_response","input":{}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"answer\":42}"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":10}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`)
+
+	client := New(
+		WithAPIKey("test-key"),
+		WithEndpoint(server.URL),
+		WithResponseFormat(&ResponseFormat{
+			Type: ResponseFormatTypeJSONSchema,
+			Schema: &Schema{
+				Type:       Object,
+				Properties: map[string]*Property{"answer": {Type: Integer}},
+			},
+		}),
+	)
+
+	response, err := client.StreamToResponse(context.Background(), Messages{NewUserTextMessage("What is the answer?")}, nil)
+	if err != nil {
+		t.Fatalf("StreamToResponse failed: %v", err)
+	}
+	if len(response.ToolCalls()) != 0 || response.Message().Text() != `{"answer":42}` {
+		t.Errorf("Expected structured output text, got %+v", response.Content)
+	}
+	if response.StopReason != StopReasonEndTurn {
+		t.Errorf("Expected stop reason %q, got %q", StopReasonEndTurn, response.StopReason)
+	}
+}
+
+func TestApplyRequestConfig_ResponseFormatPrefill(t *testing.T) {
+	client := New(
+		WithPrefill("{", ""),
+		WithResponseFormat(&ResponseFormat{Type: ResponseFormatTypeJSON}),
+	)
+
+	var request Request
+	if err := client.applyRequestConfig(&request); err == nil {
+		t.Error("Expected error for prefill with a JSON response format")
+	}
+
+	client = New(
+		WithPrefill("Answer:", ""),
+		WithResponseFormat(&ResponseFormat{Type: ResponseFormatTypeText}),
+	)
+	if err := client.applyRequestConfig(&Request{}); err != nil {
+		t.Errorf("Expected prefill to be allowed with a text response format, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"math"
 	"math/rand"
 	"time"
@@ -10,11 +11,21 @@ import (
 const (
 	MaxRetries = 3
 	BaseWait   = 2 * time.Second
+	MaxWait    = 60 * time.Second
 )
 
+// RetryAfterError is an error that indicates how long to wait before the
+// next attempt, for example based on a retry-after response header.
+type RetryAfterError interface {
+	error
+	RetryAfter() time.Duration
+}
+
 type retryConfig struct {
 	MaxRetries int
 	BaseWait   time.Duration
+	MaxWait    time.Duration
+	OnRetry    func(attempt int, wait time.Duration, err error)
 }
 
 type Option func(*retryConfig)
@@ -31,6 +42,23 @@ func WithBaseWait(baseWait time.Duration) Option {
 	}
 }
 
+// WithMaxWait sets the maximum time to wait when an error requests a specific
+// retry delay via RetryAfterError.
+func WithMaxWait(maxWait time.Duration) Option {
+	return func(c *retryConfig) {
+		c.MaxWait = maxWait
+	}
+}
+
+// WithOnRetry sets a function that is called before each retry with the
+// attempt number, starting at 1, the time until the retry and the error that
+// caused it.
+func WithOnRetry(onRetry func(attempt int, wait time.Duration, err error)) Option {
+	return func(c *retryConfig) {
+		c.OnRetry = onRetry
+	}
+}
+
 // RetryableFunc represents a function that can be retried
 type RetryableFunc func() error
 
@@ -41,6 +69,7 @@ func Do(ctx context.Context, f RetryableFunc, opts ...Option) error {
 	config := &retryConfig{
 		MaxRetries: MaxRetries,
 		BaseWait:   BaseWait,
+		MaxWait:    MaxWait,
 	}
 	for _, opt := range opts {
 		opt(config)
@@ -48,13 +77,14 @@ func Do(ctx context.Context, f RetryableFunc, opts ...Option) error {
 
 	for attempt := 0; attempt <= config.MaxRetries; attempt++ {
 		if attempt > 0 {
-			// Exponential backoff with jitter
-			backoff := time.Duration(float64(config.BaseWait) * math.Pow(2, float64(attempt-1)))
-			jitter := time.Duration(rand.Float64() * float64(backoff) * 0.1)
+			wait := config.wait(attempt, lastError)
+			if config.OnRetry != nil {
+				config.OnRetry(attempt, wait, lastError)
+			}
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
-			case <-time.After(backoff + jitter):
+			case <-time.After(wait):
 			}
 		}
 
@@ -69,3 +99,21 @@ func Do(ctx context.Context, f RetryableFunc, opts ...Option) error {
 	}
 	return lastError
 }
+
+// wait returns how long to wait before the given attempt. The delay requested
+// by the previous error is used if present, capped by MaxWait. Otherwise
+// exponential backoff with jitter is used.
+func (c *retryConfig) wait(attempt int, lastError error) time.Duration {
+	var retryAfter RetryAfterError
+	if errors.As(lastError, &retryAfter) {
+		if wait := retryAfter.RetryAfter(); wait > 0 {
+			if c.MaxWait > 0 && wait > c.MaxWait {
+				return c.MaxWait
+			}
+			return wait
+		}
+	}
+	backoff := time.Duration(float64(c.BaseWait) * math.Pow(2, float64(attempt-1)))
+	jitter := time.Duration(rand.Float64() * float64(backoff) * 0.1)
+	return backoff + jitter
+}
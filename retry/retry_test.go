@@ -148,3 +148,101 @@ func TestWithBaseWait(t *testing.T) {
 		t.Errorf("expected BaseWait to be 10s, got %v", config.BaseWait)
 	}
 }
+
+type retryAfterError struct {
+	wait time.Duration
+}
+
+func (e *retryAfterError) Error() string {
+	return "rate limited"
+}
+
+func (e *retryAfterError) IsRecoverable() bool {
+	return true
+}
+
+func (e *retryAfterError) RetryAfter() time.Duration {
+	return e.wait
+}
+
+func TestDo_RetryAfter(t *testing.T) {
+	callCount := 0
+	f := func() error {
+		callCount++
+		if callCount == 1 {
+			return &retryAfterError{wait: 50 * time.Millisecond}
+		}
+		return nil
+	}
+
+	start := time.Now()
+	err := Do(context.Background(), f, WithBaseWait(1*time.Millisecond))
+	duration := time.Since(start)
+
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if callCount != 2 {
+		t.Errorf("expected function to be called 2 times, got %d", callCount)
+	}
+	// Should have waited the requested time rather than the base wait
+	if duration < 50*time.Millisecond {
+		t.Errorf("expected to wait at least 50ms, but took %v", duration)
+	}
+}
+
+func TestDo_RetryAfterMaxWait(t *testing.T) {
+	callCount := 0
+	f := func() error {
+		callCount++
+		if callCount == 1 {
+			return &retryAfterError{wait: time.Hour}
+		}
+		return nil
+	}
+
+	start := time.Now()
+	err := Do(context.Background(), f, WithMaxWait(10*time.Millisecond))
+	duration := time.Since(start)
+
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if duration > time.Second {
+		t.Errorf("expected wait to be capped by max wait, but took %v", duration)
+	}
+}
+
+func TestWithMaxWait(t *testing.T) {
+	config := &retryConfig{}
+	opt := WithMaxWait(30 * time.Second)
+	opt(config)
+	if config.MaxWait != 30*time.Second {
+		t.Errorf("expected MaxWait to be 30s, got %v", config.MaxWait)
+	}
+}
+
+func TestDo_OnRetry(t *testing.T) {
+	testErr := NewRecoverableError(errors.New("recoverable error"))
+	var attempts []int
+	f := func() error {
+		return testErr
+	}
+	onRetry := func(attempt int, wait time.Duration, err error) {
+		if wait <= 0 {
+			t.Errorf("expected positive wait, got %v", wait)
+		}
+		if err != testErr {
+			t.Errorf("expected %v, got %v", testErr, err)
+		}
+		attempts = append(attempts, attempt)
+	}
+
+	err := Do(context.Background(), f, WithMaxRetries(2), WithBaseWait(time.Millisecond), WithOnRetry(onRetry))
+	if err != testErr {
+		t.Errorf("expected %v, got %v", testErr, err)
+	}
+	if len(attempts) != 2 || attempts[0] != 1 || attempts[1] != 2 {
+		t.Errorf("expected retries 1 and 2, got %v", attempts)
+	}
+}
@@ -0,0 +1,137 @@
+package anthropic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrMaxTurnsExceeded is returned by Run when the LLM is still requesting
+// tool calls after the configured maximum number of turns.
+var ErrMaxTurnsExceeded = errors.New("max turns exceeded")
+
+// Run generates a response and automatically executes any tool calls the LLM
+// requests, feeding the results back until the LLM stops requesting tools or
+// the maximum number of turns is reached. Tools are looked up by name in
+// Client.Tools and must implement CallableTool.
+//
+// The returned transcript contains the input messages followed by every
+// assistant and tool result message produced during the run. The tool call
+// results are returned in the order the calls were made. Any options are
+// applied over the client configuration for this run only.
+func (p *Client) Run(ctx context.Context, messages Messages, opts ...Option) (Messages, []*ToolCallResult, error) {
+	p = p.withOptions(opts)
+	transcript := make(Messages, len(messages))
+	copy(transcript, messages)
+
+	var results []*ToolCallResult
+	for turn := 0; turn < p.maxTurns; turn++ {
+		response, err := p.Generate(ctx, transcript)
+		if err != nil {
+			return transcript, results, err
+		}
+		transcript = append(transcript, response.Message())
+
+		toolCalls := response.ToolCalls()
+		if response.StopReason != StopReasonToolUse || len(toolCalls) == 0 {
+			return transcript, results, nil
+		}
+
+		outputs := make([]Content, 0, len(toolCalls))
+		for _, toolCall := range toolCalls {
+			result, output := p.callTool(ctx, toolCall)
+			results = append(results, result)
+			outputs = append(outputs, output)
+		}
+		transcript = append(transcript, &Message{Role: User, Content: outputs})
+	}
+	return transcript, results, fmt.Errorf("%w (%d)", ErrMaxTurnsExceeded, p.maxTurns)
+}
+
+// callTool executes a single tool call and returns both the record of the
+// call and the tool result content to send back to the LLM.
+func (p *Client) callTool(ctx context.Context, toolCall *ToolUseContent) (*ToolCallResult, *ToolResultContent) {
+	callResult := &ToolCallResult{
+		ID:     toolCall.ID,
+		Name:   toolCall.Name,
+		Input:  toolCall.Input,
+		Result: &ToolUseResult{ToolUseID: toolCall.ID},
+	}
+
+	var tool CallableTool
+	for _, t := range p.Tools {
+		if t.Name() != toolCall.Name {
+			continue
+		}
+		if callable, ok := t.(CallableTool); ok {
+			tool = callable
+		}
+		break
+	}
+	if tool == nil {
+		callResult.Error = fmt.Errorf("tool not found: %s", toolCall.Name)
+	} else {
+		result, err := tool.Call(ctx, toolCall.Input)
+		if err != nil {
+			callResult.Error = fmt.Errorf("tool %s failed: %w", toolCall.Name, err)
+		} else if result == nil {
+			callResult.Error = fmt.Errorf("tool %s returned no result", toolCall.Name)
+		} else {
+			output := &ToolResultContent{
+				ToolUseID: toolCall.ID,
+				Content:   toolResultOutput(result),
+				IsError:   result.IsError,
+			}
+			callResult.Result.Output = toolResultText(result)
+			if result.IsError {
+				callResult.Result.Error = errors.New(callResult.Result.Output)
+			}
+			return callResult, output
+		}
+	}
+
+	callResult.Result.Error = callResult.Error
+	return callResult, &ToolResultContent{
+		ToolUseID: toolCall.ID,
+		Content:   callResult.Error.Error(),
+		IsError:   true,
+	}
+}
+
+// toolResultText concatenates the text content of a tool result.
+func toolResultText(result *ToolResult) string {
+	var texts []string
+	for _, content := range result.Content {
+		if content.Type == ToolResultContentTypeText {
+			texts = append(texts, content.Text)
+		}
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// toolResultOutput converts a tool result to the content of a tool_result
+// block. Text-only results are sent as a plain string, while results that
+// include images are sent as an array of content blocks.
+func toolResultOutput(result *ToolResult) any {
+	var hasImages bool
+	for _, content := range result.Content {
+		if content.Type == ToolResultContentTypeImage {
+			hasImages = true
+			break
+		}
+	}
+	if !hasImages {
+		return toolResultText(result)
+	}
+	blocks := make([]Content, 0, len(result.Content))
+	for _, content := range result.Content {
+		switch content.Type {
+		case ToolResultContentTypeText:
+			blocks = append(blocks, &TextContent{Text: content.Text})
+		case ToolResultContentTypeImage:
+			blocks = append(blocks, &ImageContent{Source: EncodedData(content.MimeType, content.Data)})
+		}
+	}
+	return blocks
+}
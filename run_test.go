@@ -0,0 +1,199 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type weatherInput struct {
+	City string `json:"city"`
+}
+
+type weatherTool struct{}
+
+func (w *weatherTool) Name() string {
+	return "get_weather"
+}
+
+func (w *weatherTool) Description() string {
+	return "Get the weather for a city"
+}
+
+func (w *weatherTool) Schema() *Schema {
+	return &Schema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"city": {Type: String},
+		},
+		Required: []string{"city"},
+	}
+}
+
+func (w *weatherTool) Annotations() *ToolAnnotations {
+	return nil
+}
+
+func (w *weatherTool) Call(ctx context.Context, input weatherInput) (*ToolResult, error) {
+	if input.City == "" {
+		return nil, errors.New("missing city")
+	}
+	return NewToolResultText("sunny in " + input.City), nil
+}
+
+// newMockServer returns a test server that replies with the given responses
+// in order and records each request body it receives.
+func newMockServer(t *testing.T, responses []string, requests *[]map[string]any) *httptest.Server {
+	t.Helper()
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if requests != nil {
+			*requests = append(*requests, body)
+		}
+		if calls >= len(responses) {
+			t.Errorf("unexpected request %d", calls+1)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("content-type", "application/json")
+		w.Write([]byte(responses[calls]))
+		calls++
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient_Run(t *testing.T) {
+	var requests []map[string]any
+	server := newMockServer(t, []string{
+		`{"id":"msg_1","type":"message","role":"assistant","stop_reason":"tool_use","content":[
+			{"type":"text","text":"Checking."},
+			{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{"city":"Paris"}}
+		]}`,
+		`{"id":"msg_2","type":"message","role":"assistant","stop_reason":"end_turn","content":[
+			{"type":"text","text":"It is sunny in Paris."}
+		]}`,
+	}, &requests)
+
+	client := New(
+		WithAPIKey("test-key"),
+		WithEndpoint(server.URL),
+		WithTools(ToolAdapter(&weatherTool{})),
+	)
+
+	transcript, results, err := client.Run(context.Background(), Messages{
+		NewUserTextMessage("What's the weather in Paris?"),
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(transcript) != 4 {
+		t.Fatalf("Expected 4 messages in transcript, got %d", len(transcript))
+	}
+	if transcript[3].Text() != "It is sunny in Paris." {
+		t.Errorf("Unexpected final text %q", transcript[3].Text())
+	}
+
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 tool call result, got %d", len(results))
+	}
+	if results[0].Name != "get_weather" || results[0].ID != "toolu_1" {
+		t.Errorf("Unexpected tool call result %+v", results[0])
+	}
+	if results[0].Error != nil {
+		t.Errorf("Unexpected tool call error: %v", results[0].Error)
+	}
+	if results[0].Result.Output != "sunny in Paris" {
+		t.Errorf("Expected output 'sunny in Paris', got %q", results[0].Result.Output)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(requests))
+	}
+	sent := requests[1]["messages"].([]any)
+	toolResultMessage := sent[2].(map[string]any)
+	block := toolResultMessage["content"].([]any)[0].(map[string]any)
+	if block["type"] != "tool_result" || block["tool_use_id"] != "toolu_1" || block["content"] != "sunny in Paris" {
+		t.Errorf("Unexpected tool result block %v", block)
+	}
+}
+
+func TestClient_Run_ToolErrors(t *testing.T) {
+	var requests []map[string]any
+	server := newMockServer(t, []string{
+		`{"id":"msg_1","type":"message","role":"assistant","stop_reason":"tool_use","content":[
+			{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}},
+			{"type":"tool_use","id":"toolu_2","name":"unknown_tool","input":{}}
+		]}`,
+		`{"id":"msg_2","type":"message","role":"assistant","stop_reason":"end_turn","content":[
+			{"type":"text","text":"Sorry."}
+		]}`,
+	}, &requests)
+
+	client := New(
+		WithAPIKey("test-key"),
+		WithEndpoint(server.URL),
+		WithTools(ToolAdapter(&weatherTool{})),
+	)
+
+	_, results, err := client.Run(context.Background(), Messages{
+		NewUserTextMessage("What's the weather?"),
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 tool call results, got %d", len(results))
+	}
+	for _, result := range results {
+		if result.Result.Error == nil {
+			t.Errorf("Expected error for tool call %s", result.ID)
+		}
+	}
+	if results[1].Error == nil {
+		t.Error("Expected an error for the unknown tool")
+	}
+
+	sent := requests[1]["messages"].([]any)
+	blocks := sent[2].(map[string]any)["content"].([]any)
+	for _, block := range blocks {
+		if block.(map[string]any)["is_error"] != true {
+			t.Errorf("Expected is_error to be set on %v", block)
+		}
+	}
+}
+
+func TestClient_Run_MaxTurns(t *testing.T) {
+	toolUse := `{"id":"msg_1","type":"message","role":"assistant","stop_reason":"tool_use","content":[
+		{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{"city":"Paris"}}
+	]}`
+	server := newMockServer(t, []string{toolUse, toolUse}, nil)
+
+	client := New(
+		WithAPIKey("test-key"),
+		WithEndpoint(server.URL),
+		WithTools(ToolAdapter(&weatherTool{})),
+		WithMaxTurns(2),
+	)
+
+	transcript, results, err := client.Run(context.Background(), Messages{
+		NewUserTextMessage("What's the weather in Paris?"),
+	})
+	if !errors.Is(err, ErrMaxTurnsExceeded) {
+		t.Fatalf("Expected ErrMaxTurnsExceeded, got %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("Expected 2 tool call results, got %d", len(results))
+	}
+	if len(transcript) != 5 {
+		t.Errorf("Expected 5 messages in transcript, got %d", len(transcript))
+	}
+}
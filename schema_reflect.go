@@ -0,0 +1,235 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+// SchemaFor generates a Schema describing the Go struct type T. Property
+// names follow the struct's `json` tags, and fields are required unless
+// tagged with `omitempty` or `omitzero`. The following struct tags are used
+// to refine each property:
+//
+//	description:"Text describing the field"
+//	enum:"a,b,c"
+//	format:"email"
+//	pattern:"^[a-z]+$"
+//	minimum:"0" maximum:"100"
+//	minLength:"1" maxLength:"64"
+//	minItems:"1" maxItems:"10"
+func SchemaFor[T any]() (*Schema, error) {
+	t := reflect.TypeFor[T]()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("schema type must be a struct, got %s", t)
+	}
+	property, err := propertyForType(t, map[reflect.Type]bool{})
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{
+		Type:                 Object,
+		Properties:           property.Properties,
+		Required:             property.Required,
+		AdditionalProperties: property.AdditionalProperties,
+	}, nil
+}
+
+// GenerateInto generates a response whose content is structured according
+// to a schema derived from T, using SchemaFor. The structured output is
+// decoded into a T, which is returned along with the complete response.
+func GenerateInto[T any](ctx context.Context, client *Client, messages Messages) (T, *Response, error) {
+	var result T
+	schema, err := SchemaFor[T]()
+	if err != nil {
+		return result, nil, err
+	}
+	response, err := client.Generate(ctx, messages, WithResponseFormat(&ResponseFormat{
+		Type:   ResponseFormatTypeJSONSchema,
+		Schema: schema,
+	}))
+	if err != nil {
+		return result, nil, err
+	}
+	if err := response.Message().DecodeInto(&result); err != nil {
+		return result, response, fmt.Errorf("error decoding structured output: %w", err)
+	}
+	return result, response, nil
+}
+
+// propertyForType returns the property describing the given Go type. The
+// visiting map is used to detect recursive types, which are not supported.
+func propertyForType(t reflect.Type, visiting map[reflect.Type]bool) (*Property, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &Property{Type: String, Format: stringPtr("date-time")}, nil
+	}
+	if t == rawMessageType {
+		return &Property{}, nil
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &Property{Type: String}, nil
+	case reflect.Bool:
+		return &Property{Type: Boolean}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Property{Type: Integer}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Property{Type: Number}, nil
+	case reflect.Interface:
+		return &Property{}, nil
+	case reflect.Slice, reflect.Array:
+		// Byte slices are encoded as base64 strings by encoding/json
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Property{Type: String}, nil
+		}
+		items, err := propertyForType(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return &Property{Type: Array, Items: items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type: %s", t.Key())
+		}
+		return &Property{Type: Object}, nil
+	case reflect.Struct:
+		return propertyForStruct(t, visiting)
+	default:
+		return nil, fmt.Errorf("unsupported schema type: %s", t)
+	}
+}
+
+func propertyForStruct(t reflect.Type, visiting map[reflect.Type]bool) (*Property, error) {
+	if visiting[t] {
+		return nil, fmt.Errorf("recursive type not supported: %s", t)
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	property := &Property{
+		Type:                 Object,
+		Properties:           map[string]*Property{},
+		AdditionalProperties: boolPtr(false),
+	}
+	if err := addStructFields(property, t, visiting); err != nil {
+		return nil, err
+	}
+	return property, nil
+}
+
+// addStructFields adds the fields of the struct type t to the given object
+// property. Fields of embedded structs are promoted, as with encoding/json.
+func addStructFields(property *Property, t reflect.Type, visiting map[reflect.Type]bool) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if err := addStructFields(property, embedded, visiting); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fieldProperty, err := propertyForType(field.Type, visiting)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if err := applyPropertyTags(fieldProperty, field.Tag); err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		// Nil pointers, slices and maps are encoded as null by encoding/json
+		switch field.Type.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map:
+			fieldProperty.Nullable = boolPtr(true)
+		}
+		property.Properties[name] = fieldProperty
+		if !strings.Contains(options, "omitempty") && !strings.Contains(options, "omitzero") {
+			property.Required = append(property.Required, name)
+		}
+	}
+	return nil
+}
+
+// applyPropertyTags refines a property using the schema-related struct tags.
+func applyPropertyTags(property *Property, tag reflect.StructTag) error {
+	if description := tag.Get("description"); description != "" {
+		property.Description = description
+	}
+	if enum := tag.Get("enum"); enum != "" {
+		// Enum values are strings, so they can't match values of other types
+		if property.Type != String {
+			return fmt.Errorf("enum tag is only supported on string fields")
+		}
+		property.Enum = strings.Split(enum, ",")
+	}
+	if format := tag.Get("format"); format != "" {
+		property.Format = &format
+	}
+	if pattern := tag.Get("pattern"); pattern != "" {
+		property.Pattern = &pattern
+	}
+	for _, f := range []struct {
+		name  string
+		value **float64
+	}{
+		{"minimum", &property.Minimum},
+		{"maximum", &property.Maximum},
+	} {
+		if value := tag.Get(f.name); value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s tag: %q", f.name, value)
+			}
+			*f.value = &parsed
+		}
+	}
+	for _, f := range []struct {
+		name  string
+		value **int
+	}{
+		{"minLength", &property.MinLength},
+		{"maxLength", &property.MaxLength},
+		{"minItems", &property.MinItems},
+		{"maxItems", &property.MaxItems},
+	} {
+		if value := tag.Get(f.name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s tag: %q", f.name, value)
+			}
+			*f.value = &parsed
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package anthropic
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type schemaAddress struct {
+	City    string `json:"city" description:"City name"`
+	Country string `json:"country,omitempty" enum:"US,CA,MX"`
+}
+
+type schemaPerson struct {
+	Name     string          `json:"name" minLength:"1" maxLength:"64" pattern:"^[A-Z]"`
+	Age      int             `json:"age" minimum:"0" maximum:"150"`
+	Score    float64         `json:"score,omitempty"`
+	Tags     []string        `json:"tags,omitempty" minItems:"1" maxItems:"5"`
+	Address  *schemaAddress  `json:"address,omitempty"`
+	Metadata map[string]any  `json:"metadata,omitempty"`
+	Friends  []schemaAddress `json:"friends,omitempty"`
+	Ignored  string          `json:"-"`
+	private  string
+}
+
+func TestSchemaFor(t *testing.T) {
+	schema, err := SchemaFor[schemaPerson]()
+	if err != nil {
+		t.Fatalf("SchemaFor failed: %v", err)
+	}
+
+	if schema.Type != Object {
+		t.Errorf("Expected object schema, got %q", schema.Type)
+	}
+	if !reflect.DeepEqual(schema.Required, []string{"name", "age"}) {
+		t.Errorf("Unexpected required fields: %v", schema.Required)
+	}
+	if schema.AdditionalProperties == nil || *schema.AdditionalProperties {
+		t.Error("Expected additionalProperties to be false")
+	}
+	if len(schema.Properties) != 7 {
+		t.Errorf("Expected 7 properties, got %d", len(schema.Properties))
+	}
+
+	name := schema.Properties["name"]
+	if name.Type != String || *name.MinLength != 1 || *name.MaxLength != 64 || *name.Pattern != "^[A-Z]" {
+		t.Errorf("Unexpected name property: %+v", name)
+	}
+	age := schema.Properties["age"]
+	if age.Type != Integer || *age.Minimum != 0 || *age.Maximum != 150 {
+		t.Errorf("Unexpected age property: %+v", age)
+	}
+	if schema.Properties["score"].Type != Number {
+		t.Errorf("Expected score to be a number")
+	}
+	tags := schema.Properties["tags"]
+	if tags.Type != Array || tags.Items.Type != String || *tags.MinItems != 1 || *tags.MaxItems != 5 {
+		t.Errorf("Unexpected tags property: %+v", tags)
+	}
+	address := schema.Properties["address"]
+	if address.Type != Object || address.Properties["city"].Description != "City name" {
+		t.Errorf("Unexpected address property: %+v", address)
+	}
+	if !reflect.DeepEqual(address.Properties["country"].Enum, []string{"US", "CA", "MX"}) {
+		t.Errorf("Unexpected country enum: %v", address.Properties["country"].Enum)
+	}
+	if schema.Properties["friends"].Items.Type != Object {
+		t.Error("Expected friends items to be objects")
+	}
+}
+
+func TestSchemaFor_Nullable(t *testing.T) {
+	type note struct {
+		Note  *string           `json:"note"`
+		Tags  []string          `json:"tags"`
+		Attrs map[string]string `json:"attrs"`
+		Title string            `json:"title"`
+	}
+	schema, err := SchemaFor[note]()
+	if err != nil {
+		t.Fatalf("SchemaFor failed: %v", err)
+	}
+	if err := schema.ValidateJSON([]byte(`{"note":null,"tags":null,"attrs":null,"title":"Hi"}`)); err != nil {
+		t.Errorf("Expected null to be valid for pointer, slice and map fields: %v", err)
+	}
+	if err := schema.ValidateJSON([]byte(`{"note":"text","tags":["a"],"attrs":{},"title":null}`)); err == nil {
+		t.Error("Expected null to be invalid for a string field")
+	}
+}
+
+type schemaNode struct {
+	Children []*schemaNode `json:"children"`
+}
+
+func TestSchemaFor_Errors(t *testing.T) {
+	if _, err := SchemaFor[string](); err == nil {
+		t.Error("Expected error for non-struct type")
+	}
+	if _, err := SchemaFor[schemaNode](); err == nil {
+		t.Error("Expected error for recursive type")
+	}
+	if _, err := SchemaFor[struct {
+		Fn func() `json:"fn"`
+	}](); err == nil {
+		t.Error("Expected error for unsupported field type")
+	}
+	if _, err := SchemaFor[struct {
+		Level int `json:"level" enum:"1,2,3"`
+	}](); err == nil {
+		t.Error("Expected error for enum tag on a non-string field")
+	}
+}
+
+func TestGenerateInto(t *testing.T) {
+	var requests []map[string]any
+	server := newMockServer(t, []string{
+		`{"id":"msg_1","type":"message","role":"assistant","stop_reason":"tool_use","content":[
+			{"type":"tool_use","id":"toolu_1","name":"json_response","input":{"city":"Paris","country":"US"}}
+		]}`,
+	}, &requests)
+
+	client := New(WithAPIKey("test-key"), WithEndpoint(server.URL))
+
+	address, response, err := GenerateInto[schemaAddress](context.Background(), client, Messages{
+		NewUserTextMessage("Where is the Eiffel Tower?"),
+	})
+	if err != nil {
+		t.Fatalf("GenerateInto failed: %v", err)
+	}
+	if address.City != "Paris" || address.Country != "US" {
+		t.Errorf("Unexpected result: %+v", address)
+	}
+	if response == nil || response.ID != "msg_1" {
+		t.Error("Expected the response to be returned")
+	}
+	if client.ResponseFormat != nil {
+		t.Error("GenerateInto should not modify the client")
+	}
+
+	tools := requests[0]["tools"].([]any)
+	inputSchema := tools[0].(map[string]any)["input_schema"].(map[string]any)
+	if inputSchema["type"] != "object" {
+		t.Errorf("Expected object input schema, got %v", inputSchema["type"])
+	}
+}
@@ -0,0 +1,180 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationError describes one or more ways in which a value does not
+// conform to a schema.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+// Validate checks the JSON-decoded value against the schema. The value is
+// typically produced by decoding JSON into an `any`. A *ValidationError
+// listing every problem found is returned if the value is not valid.
+func (s *Schema) Validate(value any) error {
+	root := &Property{
+		Type:                 s.Type,
+		Properties:           s.Properties,
+		Required:             s.Required,
+		AdditionalProperties: s.AdditionalProperties,
+	}
+	var problems []string
+	root.validate("input", value, &problems)
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// ValidateJSON decodes the JSON data and validates it against the schema.
+func (s *Schema) ValidateJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("invalid json: %w", err)
+	}
+	return s.Validate(value)
+}
+
+// Validate checks the JSON-decoded value against the property. The path is
+// used to identify the value in any problems that are reported.
+func (p *Property) Validate(path string, value any) error {
+	var problems []string
+	p.validate(path, value, &problems)
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func (p *Property) validate(path string, value any, problems *[]string) {
+	addProblem := func(format string, args ...any) {
+		*problems = append(*problems, path+" "+fmt.Sprintf(format, args...))
+	}
+
+	if value == nil {
+		if p.Type == "" || p.Type == Null || (p.Nullable != nil && *p.Nullable) {
+			return
+		}
+		addProblem("must not be null")
+		return
+	}
+
+	switch p.Type {
+	case String:
+		s, ok := value.(string)
+		if !ok {
+			addProblem("must be a string")
+			return
+		}
+		length := utf8.RuneCountInString(s)
+		if p.MinLength != nil && length < *p.MinLength {
+			addProblem("must be at least %d characters long", *p.MinLength)
+		}
+		if p.MaxLength != nil && length > *p.MaxLength {
+			addProblem("must be at most %d characters long", *p.MaxLength)
+		}
+		if p.Pattern != nil {
+			re, err := regexp.Compile(*p.Pattern)
+			if err != nil {
+				addProblem("has an invalid pattern in its schema: %v", err)
+			} else if !re.MatchString(s) {
+				addProblem("must match pattern %q", *p.Pattern)
+			}
+		}
+	case Number, Integer:
+		n, ok := value.(float64)
+		if !ok {
+			if p.Type == Integer {
+				addProblem("must be an integer")
+			} else {
+				addProblem("must be a number")
+			}
+			return
+		}
+		if p.Type == Integer && n != math.Trunc(n) {
+			addProblem("must be an integer")
+		}
+		if p.Minimum != nil && n < *p.Minimum {
+			addProblem("must be >= %v", *p.Minimum)
+		}
+		if p.Maximum != nil && n > *p.Maximum {
+			addProblem("must be <= %v", *p.Maximum)
+		}
+	case Boolean:
+		if _, ok := value.(bool); !ok {
+			addProblem("must be a boolean")
+		}
+	case Null:
+		addProblem("must be null")
+	case Array:
+		items, ok := value.([]any)
+		if !ok {
+			addProblem("must be an array")
+			return
+		}
+		if p.MinItems != nil && len(items) < *p.MinItems {
+			addProblem("must have at least %d items", *p.MinItems)
+		}
+		if p.MaxItems != nil && len(items) > *p.MaxItems {
+			addProblem("must have at most %d items", *p.MaxItems)
+		}
+		if p.Items != nil {
+			for i, item := range items {
+				p.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, problems)
+			}
+		}
+	case Object:
+		object, ok := value.(map[string]any)
+		if !ok {
+			addProblem("must be an object")
+			return
+		}
+		for _, name := range p.Required {
+			if _, ok := object[name]; !ok {
+				addProblem("is missing required property %q", name)
+			}
+		}
+		// Sort the keys so that problems are reported in a stable order
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			property, ok := p.Properties[key]
+			if !ok {
+				if p.AdditionalProperties != nil && !*p.AdditionalProperties {
+					addProblem("has unexpected property %q", key)
+				}
+				continue
+			}
+			// A nil property places no constraints on the value
+			if property != nil {
+				property.validate(path+"."+key, object[key], problems)
+			}
+		}
+	}
+
+	if len(p.Enum) > 0 {
+		s, ok := value.(string)
+		if !ok {
+			s = fmt.Sprint(value)
+		}
+		if !slices.Contains(p.Enum, s) {
+			addProblem("must be one of [%s]", strings.Join(p.Enum, ", "))
+		}
+	}
+}
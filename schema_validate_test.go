@@ -0,0 +1,105 @@
+package anthropic
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testValidationSchema() *Schema {
+	return &Schema{
+		Type: Object,
+		Properties: map[string]*Property{
+			"name":  {Type: String, MinLength: intPtr(2), MaxLength: intPtr(10), Pattern: stringPtr("^[a-z]+$")},
+			"age":   {Type: Integer, Minimum: floatPtr(0), Maximum: floatPtr(150)},
+			"unit":  {Type: String, Enum: []string{"celsius", "fahrenheit"}},
+			"tags":  {Type: Array, Items: &Property{Type: String}, MinItems: intPtr(1), MaxItems: intPtr(3)},
+			"notes": {Type: String, Nullable: boolPtr(true)},
+			"score": {Type: Number},
+			"extra": nil,
+			"address": {
+				Type:       Object,
+				Properties: map[string]*Property{"city": {Type: String}},
+				Required:   []string{"city"},
+			},
+		},
+		Required:             []string{"name"},
+		AdditionalProperties: boolPtr(false),
+	}
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func TestSchema_ValidateJSON(t *testing.T) {
+	schema := testValidationSchema()
+
+	tests := []struct {
+		name    string
+		input   string
+		problem string
+	}{
+		{"valid", `{"name":"bob","age":30,"unit":"celsius","tags":["a"],"notes":null,"address":{"city":"Paris"}}`, ""},
+		{"unconstrained property", `{"name":"bob","extra":{"any":[1,"a"]}}`, ""},
+		{"missing required", `{"age":30}`, `input is missing required property "name"`},
+		{"wrong type", `{"name":42}`, "input.name must be a string"},
+		{"too short", `{"name":"b"}`, "input.name must be at least 2 characters long"},
+		{"too long", `{"name":"abcdefghijk"}`, "input.name must be at most 10 characters long"},
+		{"pattern", `{"name":"Bob"}`, `input.name must match pattern "^[a-z]+$"`},
+		{"not integer", `{"name":"bob","age":1.5}`, "input.age must be an integer"},
+		{"integer type", `{"name":"bob","age":"old"}`, "input.age must be an integer"},
+		{"number type", `{"name":"bob","score":"high"}`, "input.score must be a number"},
+		{"below minimum", `{"name":"bob","age":-1}`, "input.age must be >= 0"},
+		{"above maximum", `{"name":"bob","age":200}`, "input.age must be <= 150"},
+		{"enum", `{"name":"bob","unit":"kelvin"}`, "input.unit must be one of [celsius, fahrenheit]"},
+		{"too few items", `{"name":"bob","tags":[]}`, "input.tags must have at least 1 items"},
+		{"too many items", `{"name":"bob","tags":["a","b","c","d"]}`, "input.tags must have at most 3 items"},
+		{"item type", `{"name":"bob","tags":[1]}`, "input.tags[0] must be a string"},
+		{"not nullable", `{"name":null}`, "input.name must not be null"},
+		{"additional property", `{"name":"bob","other":1}`, `input has unexpected property "other"`},
+		{"nested required", `{"name":"bob","address":{}}`, `input.address is missing required property "city"`},
+		{"not an object", `[]`, "input must be an object"},
+	}
+
+	for _, test := range tests {
+		err := schema.ValidateJSON([]byte(test.input))
+		if test.problem == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", test.name, err)
+			}
+			continue
+		}
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("%s: expected a ValidationError, got %v", test.name, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.problem) {
+			t.Errorf("%s: expected %q in error, got %q", test.name, test.problem, err.Error())
+		}
+	}
+}
+
+func TestSchema_ValidateJSON_InvalidJSON(t *testing.T) {
+	if err := testValidationSchema().ValidateJSON([]byte(`{`)); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
+
+func TestTypedToolAdapter_Call_InvalidInput(t *testing.T) {
+	adapter := ToolAdapter(&weatherTool{})
+
+	result, err := adapter.Call(context.Background(), []byte(`{"city":7}`))
+	if err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if !result.IsError {
+		t.Fatal("Expected an error result")
+	}
+	expected := "invalid input for tool get_weather: input.city must be a string"
+	if result.Content[0].Text != expected {
+		t.Errorf("Expected %q, got %q", expected, result.Content[0].Text)
+	}
+}
@@ -3,6 +3,7 @@ package anthropic
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"sort"
 )
 
@@ -21,6 +22,7 @@ const (
 	EventTypeContentBlockStart EventType = "content_block_start"
 	EventTypeContentBlockDelta EventType = "content_block_delta"
 	EventTypeContentBlockStop  EventType = "content_block_stop"
+	EventTypeError             EventType = "error"
 )
 
 // Event represents a single streaming event from the LLM. A successfully
@@ -32,17 +34,26 @@ type Event struct {
 	ContentBlock *EventContentBlock `json:"content_block,omitempty"`
 	Delta        *EventDelta        `json:"delta,omitempty"`
 	Usage        *Usage             `json:"usage,omitempty"`
+	Error        *APIError          `json:"error,omitempty"`
 }
 
 // EventContentBlock carries the start of a content block in an LLM event.
+// Blocks produced by server tools, such as web search results, arrive
+// complete in this event.
 type EventContentBlock struct {
-	Type      ContentType     `json:"type"`
-	Text      string          `json:"text,omitempty"`
-	ID        string          `json:"id,omitempty"`
-	Name      string          `json:"name,omitempty"`
-	Input     json.RawMessage `json:"input,omitempty"`
-	Thinking  string          `json:"thinking,omitempty"`
-	Signature string          `json:"signature,omitempty"`
+	Type       ContentType     `json:"type"`
+	Text       string          `json:"text,omitempty"`
+	ID         string          `json:"id,omitempty"`
+	Name       string          `json:"name,omitempty"`
+	Input      json.RawMessage `json:"input,omitempty"`
+	Thinking   string          `json:"thinking,omitempty"`
+	Signature  string          `json:"signature,omitempty"`
+	Data       string          `json:"data,omitempty"`
+	ToolUseID  string          `json:"tool_use_id,omitempty"`
+	ServerName string          `json:"server_name,omitempty"`
+	IsError    bool            `json:"is_error,omitempty"`
+	Content    json.RawMessage `json:"content,omitempty"`
+	Citations  json.RawMessage `json:"citations,omitempty"`
 }
 
 // EventDeltaType indicates the type of delta in an LLM event.
@@ -62,20 +73,23 @@ const (
 
 // EventDelta carries a portion of an LLM response.
 type EventDelta struct {
-	Type         EventDeltaType `json:"type,omitempty"`
-	Text         string         `json:"text,omitempty"`
-	Index        int            `json:"index,omitempty"`
-	StopReason   string         `json:"stop_reason,omitempty"`
-	StopSequence string         `json:"stop_sequence,omitempty"`
-	PartialJSON  string         `json:"partial_json,omitempty"`
-	Thinking     string         `json:"thinking,omitempty"`
-	Signature    string         `json:"signature,omitempty"`
+	Type         EventDeltaType  `json:"type,omitempty"`
+	Text         string          `json:"text,omitempty"`
+	Index        int             `json:"index,omitempty"`
+	StopReason   string          `json:"stop_reason,omitempty"`
+	StopSequence string          `json:"stop_sequence,omitempty"`
+	PartialJSON  string          `json:"partial_json,omitempty"`
+	Thinking     string          `json:"thinking,omitempty"`
+	Signature    string          `json:"signature,omitempty"`
+	Citation     json.RawMessage `json:"citation,omitempty"`
 }
 
 // ResponseAccumulator builds up a complete response from a stream of events.
 type ResponseAccumulator struct {
 	response      *Response
-	contentBlocks map[int]Content // Map of content blocks by index
+	contentBlocks map[int]Content             // Map of content blocks by index
+	inputBuffers  map[int][]byte              // Partial JSON input of server tool use blocks
+	inputDecoders map[int]*partialJSONDecoder // Decoders for previewing tool input as it streams
 	complete      bool
 }
 
@@ -83,6 +97,8 @@ type ResponseAccumulator struct {
 func NewResponseAccumulator() *ResponseAccumulator {
 	return &ResponseAccumulator{
 		contentBlocks: make(map[int]Content),
+		inputBuffers:  make(map[int][]byte),
+		inputDecoders: make(map[int]*partialJSONDecoder),
 	}
 }
 
@@ -120,17 +136,28 @@ func (r *ResponseAccumulator) AddEvent(event *Event) error {
 				Signature: event.ContentBlock.Signature,
 			}
 		case ContentTypeRedactedThinking:
-			content = &RedactedThinkingContent{}
+			// The encrypted data must be passed back verbatim on the next turn
+			content = &RedactedThinkingContent{
+				Data: event.ContentBlock.Data,
+			}
+		default:
+			// Other blocks arrive complete, or with only their input
+			// streamed, so they are decoded the same way as blocks in a
+			// non-streamed response
+			data, err := json.Marshal(event.ContentBlock)
+			if err != nil {
+				return err
+			}
+			if content, err = UnmarshalContent(data); err != nil {
+				return err
+			}
 		}
 
+		index := len(r.contentBlocks)
 		if event.Index != nil {
-			// Store content by index in map
-			r.contentBlocks[*event.Index] = content
-		} else {
-			// If no index provided, use the next available index
-			nextIndex := len(r.contentBlocks)
-			r.contentBlocks[nextIndex] = content
+			index = *event.Index
 		}
+		r.contentBlocks[index] = content
 
 	case EventTypeContentBlockDelta:
 		if r.response == nil || event.Delta == nil || event.Index == nil {
@@ -150,11 +177,25 @@ func (r *ResponseAccumulator) AddEvent(event *Event) error {
 				return errors.New("in-progress block is not a text content")
 			}
 		case EventDeltaTypeInputJSON:
-			if toolUseContent, ok := content.(*ToolUseContent); ok {
-				toolUseContent.Input = append(toolUseContent.Input, []byte(event.Delta.PartialJSON)...)
-			} else {
+			switch c := content.(type) {
+			case *ToolUseContent:
+				c.Input = append(c.Input, []byte(event.Delta.PartialJSON)...)
+			case *ServerToolUseContent, *MCPToolUseContent:
+				// The input is decoded once the block is complete
+				r.inputBuffers[*event.Index] = append(r.inputBuffers[*event.Index], event.Delta.PartialJSON...)
+			default:
 				return errors.New("in-progress block is not a tool use content")
 			}
+		case EventDeltaTypeCitations:
+			textContent, ok := content.(*TextContent)
+			if !ok {
+				return errors.New("in-progress block is not a text content")
+			}
+			citation, err := unmarshalCitation(event.Delta.Citation)
+			if err != nil {
+				return fmt.Errorf("invalid citation: %w", err)
+			}
+			textContent.Citations = append(textContent.Citations, citation)
 		case EventDeltaTypeThinking, EventDeltaTypeSignature:
 			if thinkingContent, ok := content.(*ThinkingContent); ok {
 				thinkingContent.Thinking += event.Delta.Thinking
@@ -164,6 +205,26 @@ func (r *ResponseAccumulator) AddEvent(event *Event) error {
 			}
 		}
 
+	case EventTypeContentBlockStop:
+		if event.Index == nil {
+			break
+		}
+		delete(r.inputDecoders, *event.Index)
+		input, ok := r.inputBuffers[*event.Index]
+		if !ok {
+			break
+		}
+		delete(r.inputBuffers, *event.Index)
+		switch c := r.contentBlocks[*event.Index].(type) {
+		case *ServerToolUseContent:
+			c.Input = nil
+			if err := json.Unmarshal(input, &c.Input); err != nil {
+				return fmt.Errorf("invalid server tool use input: %w", err)
+			}
+		case *MCPToolUseContent:
+			c.Input = json.RawMessage(input)
+		}
+
 	case EventTypeMessageDelta:
 		if r.response == nil || event.Delta == nil {
 			return errors.New("invalid message delta event")
@@ -181,16 +242,53 @@ func (r *ResponseAccumulator) AddEvent(event *Event) error {
 		r.finalizeContent()
 	}
 
-	// Update usage information if provided
-	if event.Usage != nil {
-		r.response.Usage.InputTokens += event.Usage.InputTokens
-		r.response.Usage.OutputTokens += event.Usage.OutputTokens
-		r.response.Usage.CacheReadInputTokens += event.Usage.CacheReadInputTokens
-		r.response.Usage.CacheCreationInputTokens += event.Usage.CacheCreationInputTokens
+	// Update usage information if provided. The counts in message_delta
+	// events are cumulative, so they replace the counts seen so far.
+	if event.Usage != nil && r.response != nil {
+		usage := &r.response.Usage
+		if event.Usage.InputTokens > 0 {
+			usage.InputTokens = event.Usage.InputTokens
+		}
+		if event.Usage.OutputTokens > 0 {
+			usage.OutputTokens = event.Usage.OutputTokens
+		}
+		if event.Usage.CacheReadInputTokens > 0 {
+			usage.CacheReadInputTokens = event.Usage.CacheReadInputTokens
+		}
+		if event.Usage.CacheCreationInputTokens > 0 {
+			usage.CacheCreationInputTokens = event.Usage.CacheCreationInputTokens
+		}
 	}
 	return nil
 }
 
+// partialToolInput returns the tool name and the input received so far for
+// the tool use block at the given index, decoded on a best-effort basis. Only
+// the input received since the previous call is decoded.
+func (r *ResponseAccumulator) partialToolInput(index int) (string, map[string]any) {
+	var name string
+	var data []byte
+	switch c := r.contentBlocks[index].(type) {
+	case *ToolUseContent:
+		name, data = c.Name, c.Input
+	case *ServerToolUseContent:
+		name, data = c.Name, r.inputBuffers[index]
+	case *MCPToolUseContent:
+		name, data = c.Name, r.inputBuffers[index]
+	default:
+		return "", nil
+	}
+	decoder, ok := r.inputDecoders[index]
+	if !ok || decoder.written > len(data) {
+		decoder = &partialJSONDecoder{}
+		r.inputDecoders[index] = decoder
+	}
+	decoder.Write(data[decoder.written:])
+	value, _ := decoder.Value()
+	input, _ := value.(map[string]any)
+	return name, input
+}
+
 // finalizeContent converts the content blocks map to a sorted slice
 func (r *ResponseAccumulator) finalizeContent() {
 	if r.response == nil || len(r.contentBlocks) == 0 {
@@ -1,35 +1,112 @@
 package anthropic
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io"
-	"strings"
+	"iter"
+	"log/slog"
+	"net/http"
 	"sync"
 )
 
+// ErrStreamTruncated indicates that a stream ended before the message_stop
+// event was received. Errors returned by StreamIterator.Err wrap this.
+var ErrStreamTruncated = errors.New("stream ended before message_stop")
+
 // StreamIterator implements the StreamIterator interface
 type StreamIterator struct {
-	reader            *ServerSentEventsReader[Event]
-	body              io.ReadCloser
-	err               error
-	currentEvent      *Event
-	prefill           string
-	prefillClosingTag string
-	closeOnce         sync.Once
+	reader       *ServerSentEventsReader[Event]
+	body         io.ReadCloser
+	header       http.Header
+	err          error
+	currentEvent *Event
+	prefill      string
+	stopped      bool
+	closeOnce    sync.Once
+	accumulator  *ResponseAccumulator
+	logger       *slog.Logger
+	ctx          context.Context
+
+	// accumulateErr is set if the response could not be accumulated, such as
+	// when a block type is not recognized. Events are still returned by Next.
+	accumulateErr      error
+	onPartialToolInput PartialToolInputCallback
+}
+
+// PartialToolInputCallback is called each time more of the input of a tool
+// use block is streamed. The input is decoded on a best-effort basis in the
+// same way as ParsePartialJSON and is nil if nothing could be decoded yet. It
+// is shared with later calls and must not be modified.
+type PartialToolInputCallback func(index int, name string, input map[string]any)
+
+// newStreamIterator returns an iterator over the events read from body. The
+// header is that of the HTTP response and is included in stream errors.
+func newStreamIterator(body io.ReadCloser, header http.Header, prefill string) *StreamIterator {
+	return &StreamIterator{
+		body:        body,
+		header:      header,
+		reader:      NewServerSentEventsReader[Event](body),
+		prefill:     prefill,
+		accumulator: NewResponseAccumulator(),
+		logger:      discardLogger,
+		ctx:         context.Background(),
+	}
 }
 
 // Next advances to the next event in the stream. Returns true if an event was
 // successfully read, false when the stream is complete or an error occurs.
+// If the API sends an error event, Err returns it as a *ClientError. If the
+// stream ends without a message_stop event, Err returns an error wrapping
+// ErrStreamTruncated.
 func (s *StreamIterator) Next() bool {
 	for {
+		if s.stopped {
+			s.Close()
+			return false
+		}
 		event, ok := s.reader.Next()
 		if !ok {
 			s.err = s.reader.Err()
+			if s.err == nil {
+				s.err = ErrStreamTruncated
+			} else {
+				s.err = fmt.Errorf("%w: %w", ErrStreamTruncated, s.err)
+			}
+			s.logger.WarnContext(s.ctx, "anthropic stream failed", slog.String("error", s.err.Error()))
 			s.Close()
 			return false
 		}
+		if event.Type == EventTypeError {
+			if event.Error == nil {
+				event.Error = &APIError{Type: ErrorTypeAPI, Message: "unknown stream error"}
+			}
+			s.err = newAPIError(event.Error, s.header)
+			s.logger.WarnContext(s.ctx, "anthropic stream failed", slog.String("error", s.err.Error()))
+			s.Close()
+			return false
+		}
+		if event.Type == EventTypeMessageStop {
+			s.stopped = true
+		}
 		processedEvent := s.processEvent(&event)
 		if processedEvent != nil {
+			if s.accumulateErr == nil {
+				if err := s.accumulator.AddEvent(processedEvent); err != nil {
+					s.accumulateErr = fmt.Errorf("error accumulating response: %w", err)
+				}
+			}
 			s.currentEvent = processedEvent
+			if processedEvent.Type == EventTypeMessageStop {
+				logResponse(s.ctx, s.logger, "anthropic stream completed", s.accumulator.Response())
+			}
+			if s.onPartialToolInput != nil && processedEvent.Type == EventTypeContentBlockDelta &&
+				processedEvent.Delta != nil && processedEvent.Delta.Type == EventDeltaTypeInputJSON &&
+				processedEvent.Index != nil {
+				name, input := s.accumulator.partialToolInput(*processedEvent.Index)
+				s.onPartialToolInput(*processedEvent.Index, name, input)
+			}
 			return true
 		}
 	}
@@ -40,26 +117,118 @@ func (s *StreamIterator) Event() *Event {
 	return s.currentEvent
 }
 
+// Response returns the complete response accumulated from the stream, or nil
+// if the stream has not completed successfully or contained blocks that could
+// not be accumulated. The prefill, if any, is included in the response text.
+func (s *StreamIterator) Response() *Response {
+	if s.err != nil || s.accumulateErr != nil || !s.accumulator.IsComplete() {
+		return nil
+	}
+	return s.accumulator.Response()
+}
+
+// Usage returns a copy of the token usage reported so far. Usage is updated as
+// events arrive and is final once the stream has completed. Returns nil if
+// the stream has not started.
+func (s *StreamIterator) Usage() *Usage {
+	if s.accumulator.response == nil {
+		return nil
+	}
+	return s.accumulator.Usage().Copy()
+}
+
+// PartialToolInput returns the input of the tool use block at the given index
+// as received so far, decoded on a best-effort basis in the same way as
+// ParsePartialJSON. This allows previewing long tool inputs while they
+// stream. The input is decoded incrementally, so values that are complete are
+// shared between calls and must not be modified. Returns nil if the block is
+// not a tool use block or nothing could be decoded yet.
+func (s *StreamIterator) PartialToolInput(index int) map[string]any {
+	_, input := s.accumulator.partialToolInput(index)
+	return input
+}
+
 // processEvent processes an Anthropic event and applies prefill logic if needed
 func (s *StreamIterator) processEvent(event *Event) *Event {
 	if event.Type == "" {
 		return nil
 	}
 
-	// Apply prefill logic for the first text content block
+	// Apply prefill logic for the first text content block. The closing tag
+	// can't be checked here since the text has not been streamed yet.
 	if s.prefill != "" && event.Type == EventTypeContentBlockStart {
 		if event.ContentBlock != nil && event.ContentBlock.Type == ContentTypeText {
 			// Add prefill to the beginning of the text
-			if s.prefillClosingTag == "" || strings.Contains(event.ContentBlock.Text, s.prefillClosingTag) {
-				event.ContentBlock.Text = s.prefill + event.ContentBlock.Text
-				s.prefill = "" // Only apply prefill once
-			}
+			event.ContentBlock.Text = s.prefill + event.ContentBlock.Text
+			s.prefill = "" // Only apply prefill once
 		}
 	}
 
 	return event
 }
 
+// All returns an iterator over the events in the stream. If the stream fails,
+// the final iteration yields the error. The stream is closed when iteration
+// stops, including when the loop exits early.
+func (s *StreamIterator) All() iter.Seq2[*Event, error] {
+	return func(yield func(*Event, error) bool) {
+		defer s.Close()
+		for s.Next() {
+			if !yield(s.Event(), nil) {
+				return
+			}
+		}
+		if err := s.Err(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// TextDeltas returns an iterator over the text chunks in the stream, including
+// any prefill. Other events are skipped. Errors and closing are handled as in
+// All.
+func (s *StreamIterator) TextDeltas() iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		for event, err := range s.All() {
+			if err != nil {
+				yield("", err)
+				return
+			}
+			var text string
+			switch {
+			case event.Type == EventTypeContentBlockStart && event.ContentBlock != nil &&
+				event.ContentBlock.Type == ContentTypeText:
+				text = event.ContentBlock.Text
+			case event.Type == EventTypeContentBlockDelta && event.Delta != nil &&
+				event.Delta.Type == EventDeltaTypeText:
+				text = event.Delta.Text
+			}
+			if text != "" && !yield(text, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Blocks returns an iterator over the content blocks in the stream, each
+// yielded once it is complete. Errors and closing are handled as in All.
+func (s *StreamIterator) Blocks() iter.Seq2[Content, error] {
+	return func(yield func(Content, error) bool) {
+		for event, err := range s.All() {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if event.Type != EventTypeContentBlockStop || event.Index == nil {
+				continue
+			}
+			if block := s.accumulator.contentBlocks[*event.Index]; block != nil && !yield(block, nil) {
+				return
+			}
+		}
+	}
+}
+
 func (s *StreamIterator) Close() error {
 	var err error
 	s.closeOnce.Do(func() { err = s.body.Close() })
@@ -1,6 +1,13 @@
 package anthropic
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -16,6 +23,7 @@ func TestEventType_String(t *testing.T) {
 		{EventTypeContentBlockStart, "content_block_start"},
 		{EventTypeContentBlockDelta, "content_block_delta"},
 		{EventTypeContentBlockStop, "content_block_stop"},
+		{EventTypeError, "error"},
 	}
 
 	for _, test := range tests {
@@ -160,3 +168,487 @@ func TestResponseAccumulator_AddEvent_MessageStop(t *testing.T) {
 		t.Error("Accumulator should be complete after message_stop")
 	}
 }
+
+// newStreamServer returns a test server that replies with the given
+// server-sent events body.
+func newStreamServer(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "text/event-stream")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// collectEvents streams a response from the server and returns the events
+// received along with the final stream error.
+func collectEvents(t *testing.T, server *httptest.Server) ([]*Event, error) {
+	t.Helper()
+	client := New(WithAPIKey("test-key"), WithEndpoint(server.URL))
+	stream, err := client.Stream(context.Background(), Messages{NewUserTextMessage("Hi")})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	var events []*Event
+	for stream.Next() {
+		events = append(events, stream.Event())
+	}
+	return events, stream.Err()
+}
+
+func TestStreamIterator_ErrorEvent(t *testing.T) {
+	server := newStreamServer(t, `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[]}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: error
+data: {"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}
+
+`)
+
+	events, err := collectEvents(t, server)
+	if len(events) != 2 {
+		t.Errorf("Expected 2 events before the error, got %d", len(events))
+	}
+	var clientErr *ClientError
+	if !errors.As(err, &clientErr) {
+		t.Fatalf("Expected a ClientError, got %v", err)
+	}
+	if clientErr.ErrorType() != ErrorTypeOverloaded || clientErr.Message() != "Overloaded" {
+		t.Errorf("Unexpected error: %v", clientErr)
+	}
+	if clientErr.StatusCode() != StatusOverloaded {
+		t.Errorf("Expected status %d, got %d", StatusOverloaded, clientErr.StatusCode())
+	}
+	if !errors.Is(err, ErrOverloaded) {
+		t.Error("Expected error to match ErrOverloaded")
+	}
+}
+
+func TestStreamIterator_ErrorEventHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "text/event-stream")
+		w.Header().Set("request-id", "req_123")
+		w.Header().Set("anthropic-ratelimit-requests-limit", "50")
+		w.Header().Set("anthropic-ratelimit-requests-remaining", "49")
+		w.Write([]byte(`event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[]}}
+
+event: error
+data: {"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}
+
+`))
+	}))
+	t.Cleanup(server.Close)
+
+	_, err := collectEvents(t, server)
+	var clientErr *ClientError
+	if !errors.As(err, &clientErr) {
+		t.Fatalf("Expected a ClientError, got %v", err)
+	}
+	if clientErr.RequestID() != "req_123" {
+		t.Errorf("Expected request ID req_123, got %q", clientErr.RequestID())
+	}
+	limits := clientErr.RateLimits()
+	if limits == nil || limits.Requests.Limit != 50 || limits.Requests.Remaining != 49 {
+		t.Errorf("Unexpected rate limits: %+v", limits)
+	}
+}
+
+func TestStreamIterator_Truncated(t *testing.T) {
+	server := newStreamServer(t, `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[]}}
+
+`)
+
+	events, err := collectEvents(t, server)
+	if len(events) != 1 {
+		t.Errorf("Expected 1 event, got %d", len(events))
+	}
+	if !errors.Is(err, ErrStreamTruncated) {
+		t.Errorf("Expected ErrStreamTruncated, got %v", err)
+	}
+}
+
+func TestStreamIterator_Complete(t *testing.T) {
+	server := newStreamServer(t, `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[]}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`)
+
+	events, err := collectEvents(t, server)
+	if len(events) != 2 {
+		t.Errorf("Expected 2 events, got %d", len(events))
+	}
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestResponseAccumulator_ServerToolsAndCitations(t *testing.T) {
+	events := []string{
+		`{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[]}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"server_tool_use","id":"srvtoolu_1","name":"web_search","input":{}}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"query\": \"claude"}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":" shannon\"}"}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"web_search_tool_result","tool_use_id":"srvtoolu_1","content":[{"type":"web_search_result","url":"https://en.wikipedia.org/wiki/Claude_Shannon","title":"Claude Shannon","encrypted_content":"EqgfCioIARgB","page_age":"April 30, 2025"}]}}`,
+		`{"type":"content_block_stop","index":1}`,
+		`{"type":"content_block_start","index":2,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":2,"delta":{"type":"citations_delta","citation":{"type":"web_search_result_location","url":"https://en.wikipedia.org/wiki/Claude_Shannon","title":"Claude Shannon","encrypted_index":"Eo8BCioIAhgB","cited_text":"Claude Elwood Shannon (April 30, 1916"}}}`,
+		`{"type":"content_block_delta","index":2,"delta":{"type":"text_delta","text":"Shannon was born on April 30, 1916."}}`,
+		`{"type":"content_block_stop","index":2}`,
+		`{"type":"content_block_start","index":3,"content_block":{"type":"code_execution_tool_result","tool_use_id":"srvtoolu_2","content":{"type":"code_execution_result","stdout":"42\n","stderr":"","return_code":0}}}`,
+		`{"type":"content_block_stop","index":3}`,
+		`{"type":"content_block_start","index":4,"content_block":{"type":"mcp_tool_use","id":"mcptoolu_1","name":"echo","server_name":"example-mcp","input":{}}}`,
+		`{"type":"content_block_delta","index":4,"delta":{"type":"input_json_delta","partial_json":"{\"text\":\"hi\"}"}}`,
+		`{"type":"content_block_stop","index":4}`,
+		`{"type":"content_block_start","index":5,"content_block":{"type":"mcp_tool_result","tool_use_id":"mcptoolu_1","is_error":false,"content":[{"type":"text","text":"hi"}]}}`,
+		`{"type":"content_block_stop","index":5}`,
+		`{"type":"content_block_start","index":6,"content_block":{"type":"web_search_tool_result","tool_use_id":"srvtoolu_3","content":{"type":"web_search_tool_result_error","error_code":"max_uses_exceeded"}}}`,
+		`{"type":"content_block_stop","index":6}`,
+		`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":50}}`,
+		`{"type":"message_stop"}`,
+	}
+	accumulator := NewResponseAccumulator()
+	for _, data := range events {
+		var event Event
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			t.Fatalf("Failed to unmarshal event %s: %v", data, err)
+		}
+		if err := accumulator.AddEvent(&event); err != nil {
+			t.Fatalf("AddEvent failed for %s: %v", data, err)
+		}
+	}
+
+	var expected Response
+	err := json.Unmarshal([]byte(`{"id":"msg_1","type":"message","role":"assistant","content":[
+		{"type":"server_tool_use","id":"srvtoolu_1","name":"web_search","input":{"query":"claude shannon"}},
+		{"type":"web_search_tool_result","tool_use_id":"srvtoolu_1","content":[{"type":"web_search_result","url":"https://en.wikipedia.org/wiki/Claude_Shannon","title":"Claude Shannon","encrypted_content":"EqgfCioIARgB","page_age":"April 30, 2025"}]},
+		{"type":"text","text":"Shannon was born on April 30, 1916.","citations":[{"type":"web_search_result_location","url":"https://en.wikipedia.org/wiki/Claude_Shannon","title":"Claude Shannon","encrypted_index":"Eo8BCioIAhgB","cited_text":"Claude Elwood Shannon (April 30, 1916"}]},
+		{"type":"code_execution_tool_result","tool_use_id":"srvtoolu_2","content":{"type":"code_execution_result","stdout":"42\n","stderr":"","return_code":0}},
+		{"type":"mcp_tool_use","id":"mcptoolu_1","name":"echo","server_name":"example-mcp","input":{"text":"hi"}},
+		{"type":"mcp_tool_result","tool_use_id":"mcptoolu_1","is_error":false,"content":[{"type":"text","text":"hi"}]},
+		{"type":"web_search_tool_result","tool_use_id":"srvtoolu_3","content":{"type":"web_search_tool_result_error","error_code":"max_uses_exceeded"}}
+	]}`), &expected)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal expected response: %v", err)
+	}
+
+	response := accumulator.Response()
+	streamed, _ := json.Marshal(response.Content)
+	direct, _ := json.Marshal(expected.Content)
+	if string(streamed) != string(direct) {
+		t.Errorf("Streamed content differs from non-streamed content:\nstreamed: %s\ndirect:   %s", streamed, direct)
+	}
+
+	if result, ok := response.Content[6].(*WebSearchToolResultContent); !ok || result.ErrorCode != "max_uses_exceeded" {
+		t.Errorf("Expected web search error code, got %+v", response.Content[6])
+	}
+	if text, ok := response.Content[2].(*TextContent); !ok || len(text.Citations) != 1 {
+		t.Errorf("Expected text with one citation, got %+v", response.Content[2])
+	}
+}
+
+func TestResponseAccumulator_RedactedThinking(t *testing.T) {
+	events := []string{
+		`{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[]}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Let me check the weather."}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"EqQBCgIYAhIM"}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"redacted_thinking","data":"EmwKAhgBEgy3va3pzix"}}`,
+		`{"type":"content_block_stop","index":1}`,
+		`{"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}}`,
+		`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"city\":\"Paris\"}"}}`,
+		`{"type":"content_block_stop","index":2}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use"}}`,
+		`{"type":"message_stop"}`,
+	}
+	accumulator := NewResponseAccumulator()
+	for _, data := range events {
+		var event Event
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			t.Fatalf("Failed to unmarshal event %s: %v", data, err)
+		}
+		if err := accumulator.AddEvent(&event); err != nil {
+			t.Fatalf("AddEvent failed for %s: %v", data, err)
+		}
+	}
+
+	message := accumulator.Response().Message()
+	redacted, ok := message.Content[1].(*RedactedThinkingContent)
+	if !ok || redacted.Data != "EmwKAhgBEgy3va3pzix" {
+		t.Fatalf("Expected redacted thinking data to be preserved, got %+v", message.Content[1])
+	}
+
+	// The blocks must be sent back unchanged on the next turn
+	client := New(WithMaxTokens(8192), WithReasoningBudget(2048))
+	req, err := client.buildRequest(Messages{
+		NewUserTextMessage("What is the weather in Paris?"),
+		message,
+		NewToolResultMessage(&ToolResultContent{ToolUseID: "toolu_1", Content: "Sunny"}),
+	})
+	if err != nil {
+		t.Fatalf("buildRequest failed: %v", err)
+	}
+	body, err := json.Marshal(req.Messages[1])
+	if err != nil {
+		t.Fatalf("Failed to marshal message: %v", err)
+	}
+	expected := `{"role":"assistant","content":[` +
+		`{"type":"thinking","thinking":"Let me check the weather.","signature":"EqQBCgIYAhIM"},` +
+		`{"type":"redacted_thinking","data":"EmwKAhgBEgy3va3pzix"},` +
+		`{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{"city":"Paris"}}]}`
+	if string(body) != expected {
+		t.Errorf("Unexpected assistant message:\ngot:  %s\nwant: %s", body, expected)
+	}
+}
+
+const textAndToolStream = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[]}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Checking "}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"the weather."}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\":\"Paris\"}"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":1}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":20}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+
+// closeTracker records whether a stream body was closed.
+type closeTracker struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed = true
+	return nil
+}
+
+func newTestStreamIterator(body string) (*StreamIterator, *closeTracker) {
+	tracker := &closeTracker{Reader: strings.NewReader(body)}
+	return newStreamIterator(tracker, nil, ""), tracker
+}
+
+func TestStreamIterator_All(t *testing.T) {
+	stream, tracker := newTestStreamIterator(textAndToolStream)
+	var count int
+	for event, err := range stream.All() {
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if event == nil {
+			t.Fatal("Expected an event")
+		}
+		count++
+	}
+	if count != 10 {
+		t.Errorf("Expected 10 events, got %d", count)
+	}
+	if !tracker.closed {
+		t.Error("Expected the stream to be closed")
+	}
+
+	stream, tracker = newTestStreamIterator(textAndToolStream)
+	for range stream.All() {
+		break
+	}
+	if !tracker.closed {
+		t.Error("Expected the stream to be closed after an early break")
+	}
+
+	stream, _ = newTestStreamIterator(textAndToolStream[:strings.Index(textAndToolStream, "event: message_stop")])
+	var lastErr error
+	for _, err := range stream.All() {
+		lastErr = err
+	}
+	if !errors.Is(lastErr, ErrStreamTruncated) {
+		t.Errorf("Expected ErrStreamTruncated as the final value, got %v", lastErr)
+	}
+}
+
+func TestStreamIterator_TextDeltas(t *testing.T) {
+	stream, _ := newTestStreamIterator(textAndToolStream)
+	stream.prefill = "Sure. "
+	var text strings.Builder
+	for chunk, err := range stream.TextDeltas() {
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		text.WriteString(chunk)
+	}
+	if text.String() != "Sure. Checking the weather." {
+		t.Errorf("Unexpected text %q", text.String())
+	}
+}
+
+func TestStreamIterator_Blocks(t *testing.T) {
+	stream, tracker := newTestStreamIterator(textAndToolStream)
+	var blocks []Content
+	for block, err := range stream.Blocks() {
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		blocks = append(blocks, block)
+	}
+	if len(blocks) != 2 {
+		t.Fatalf("Expected 2 blocks, got %d", len(blocks))
+	}
+	if text, ok := blocks[0].(*TextContent); !ok || text.Text != "Checking the weather." {
+		t.Errorf("Unexpected first block: %+v", blocks[0])
+	}
+	if toolUse, ok := blocks[1].(*ToolUseContent); !ok || string(toolUse.Input) != `{"city":"Paris"}` {
+		t.Errorf("Unexpected second block: %+v", blocks[1])
+	}
+	if !tracker.closed {
+		t.Error("Expected the stream to be closed")
+	}
+}
+
+func TestStreamIterator_ResponseAndUsage(t *testing.T) {
+	body := strings.Replace(textAndToolStream,
+		`"role":"assistant","content":[]}`,
+		`"role":"assistant","content":[],"usage":{"input_tokens":25,"output_tokens":1}}`, 1)
+	stream, _ := newTestStreamIterator(body)
+	if stream.Usage() != nil {
+		t.Error("Expected no usage before the stream starts")
+	}
+	var outputTokens []int
+	for stream.Next() {
+		if stream.Event().Type != EventTypeMessageStop && stream.Response() != nil {
+			t.Error("Expected no response before the stream completes")
+		}
+		outputTokens = append(outputTokens, stream.Usage().OutputTokens)
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if outputTokens[0] != 1 || outputTokens[len(outputTokens)-1] != 20 {
+		t.Errorf("Unexpected output token progression: %v", outputTokens)
+	}
+
+	response := stream.Response()
+	if response == nil {
+		t.Fatal("Expected a response")
+	}
+	if response.StopReason != StopReasonToolUse || len(response.ToolCalls()) != 1 {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+	if usage := stream.Usage(); usage.InputTokens != 25 || usage.OutputTokens != 20 {
+		t.Errorf("Unexpected usage: %+v", usage)
+	}
+}
+
+func TestClient_StreamToResponse(t *testing.T) {
+	server := newStreamServer(t, textAndToolStream)
+	client := New(WithAPIKey("test-key"), WithEndpoint(server.URL))
+
+	var events int
+	response, err := client.StreamToResponse(context.Background(), Messages{NewUserTextMessage("Hi")}, func(event *Event) {
+		events++
+	})
+	if err != nil {
+		t.Fatalf("StreamToResponse failed: %v", err)
+	}
+	if events != 10 {
+		t.Errorf("Expected 10 events, got %d", events)
+	}
+
+	var expected Response
+	err = json.Unmarshal([]byte(`{"id":"msg_1","type":"message","role":"assistant","content":[
+		{"type":"text","text":"Checking the weather."},
+		{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{"city":"Paris"}}
+	],"stop_reason":"tool_use","usage":{"input_tokens":0,"output_tokens":20}}`), &expected)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal expected response: %v", err)
+	}
+	got, _ := json.Marshal(response)
+	want, _ := json.Marshal(&expected)
+	if string(got) != string(want) {
+		t.Errorf("Unexpected response:\ngot:  %s\nwant: %s", got, want)
+	}
+
+	truncated := newStreamServer(t, textAndToolStream[:strings.Index(textAndToolStream, "event: message_stop")])
+	client = New(WithAPIKey("test-key"), WithEndpoint(truncated.URL))
+	if _, err := client.StreamToResponse(context.Background(), Messages{NewUserTextMessage("Hi")}, nil); !errors.Is(err, ErrStreamTruncated) {
+		t.Errorf("Expected ErrStreamTruncated, got %v", err)
+	}
+}
+
+func TestStreamIterator_UnknownBlockType(t *testing.T) {
+	const body = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[]}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"web_fetch_tool_result","tool_use_id":"srvtoolu_1","content":{}}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Fetched."}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":1}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":5}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+	server := newStreamServer(t, body)
+	events, err := collectEvents(t, server)
+	if err != nil {
+		t.Fatalf("Expected no stream error, got %v", err)
+	}
+	if len(events) != 8 {
+		t.Errorf("Expected 8 events, got %d", len(events))
+	}
+
+	stream, _ := newTestStreamIterator(body)
+	for stream.Next() {
+	}
+	if stream.Response() != nil {
+		t.Error("Expected no response when a block could not be accumulated")
+	}
+
+	client := New(WithAPIKey("test-key"), WithEndpoint(server.URL))
+	_, err = client.StreamToResponse(context.Background(), Messages{NewUserTextMessage("Hi")}, nil)
+	if err == nil || !strings.Contains(err.Error(), "web_fetch_tool_result") {
+		t.Errorf("Expected an accumulation error, got %v", err)
+	}
+}
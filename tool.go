@@ -18,6 +18,15 @@ type ToolInterface interface {
 	Schema() *Schema
 }
 
+// CallableTool is a tool that can be executed locally, such as a
+// TypedToolAdapter. Client.Run uses this to dispatch tool calls.
+type CallableTool interface {
+	ToolInterface
+
+	// Call executes the tool with the given input.
+	Call(ctx context.Context, input any) (*ToolResult, error)
+}
+
 type Tool struct {
 	Name        string `json:"name"`
 	Description string `json:"description,omitempty"`
@@ -201,6 +210,15 @@ func (t *TypedToolAdapter[T]) Call(ctx context.Context, input any) (*ToolResult,
 		}
 	}
 
+	// Validate the input against the tool schema, so that the LLM receives
+	// a descriptive error it can use to correct its input
+	if schema := t.tool.Schema(); schema != nil && schema.Type != "" {
+		if err := schema.ValidateJSON(data); err != nil {
+			errMessage := fmt.Sprintf("invalid input for tool %s: %v", t.Name(), err)
+			return NewToolResultError(errMessage), nil
+		}
+	}
+
 	// Unmarshal into the typed input
 	var typedInput T
 	err = json.Unmarshal(data, &typedInput)
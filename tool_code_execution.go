@@ -52,6 +52,11 @@ func (t *CodeExecutionTool) ToolConfiguration(providerName string) map[string]an
 	return map[string]any{"type": t.typeString, "name": t.name}
 }
 
+// BetaFeatures returns the beta features required to use the tool.
+func (t *CodeExecutionTool) BetaFeatures() []BetaFeature {
+	return []BetaFeature{BetaCodeExecution}
+}
+
 func (t *CodeExecutionTool) Annotations() *ToolAnnotations {
 	return &ToolAnnotations{
 		Title:           "Code Execution",
@@ -2,6 +2,7 @@ package anthropic
 
 import (
 	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 )
@@ -29,6 +30,18 @@ func (r ReasoningEffort) IsValid() bool {
 		r == ReasoningEffortHigh
 }
 
+// MinReasoningBudget is the minimum number of thinking tokens accepted by the
+// Anthropic API when extended thinking is enabled.
+const MinReasoningBudget = 1024
+
+// ReasoningEffortBudgets maps each reasoning effort level to the thinking
+// token budget that is requested when that effort level is used.
+var ReasoningEffortBudgets = map[ReasoningEffort]int{
+	ReasoningEffortLow:    MinReasoningBudget,
+	ReasoningEffortMedium: 4096,
+	ReasoningEffortHigh:   16384,
+}
+
 func (c CacheControlType) String() string {
 	return string(c)
 }
@@ -39,22 +52,44 @@ type ImageSource struct {
 	Data      string `json:"data"`
 }
 
+// ThinkingTypeEnabled is the Thinking type used to enable extended thinking.
+const ThinkingTypeEnabled = "enabled"
+
 type Thinking struct {
 	Type         string `json:"type"` // "enabled"
 	BudgetTokens int    `json:"budget_tokens"`
 }
 
 type Request struct {
-	Model       string            `json:"model"`
-	Messages    []*Message        `json:"messages"`
-	MaxTokens   *int              `json:"max_tokens,omitempty"`
-	Temperature *float64          `json:"temperature,omitempty"`
-	System      string            `json:"system,omitempty"`
-	Stream      bool              `json:"stream,omitempty"`
-	Tools       []map[string]any  `json:"tools,omitempty"`
-	ToolChoice  *ToolChoice       `json:"tool_choice,omitempty"`
-	Thinking    *Thinking         `json:"thinking,omitempty"`
-	MCPServers  []MCPServerConfig `json:"mcp_servers,omitempty"`
+	Model         string            `json:"model"`
+	Messages      []*Message        `json:"messages"`
+	MaxTokens     *int              `json:"max_tokens,omitempty"`
+	Temperature   *float64          `json:"temperature,omitempty"`
+	TopP          *float64          `json:"top_p,omitempty"`
+	TopK          *int              `json:"top_k,omitempty"`
+	StopSequences []string          `json:"stop_sequences,omitempty"`
+	System        []*TextContent    `json:"system,omitempty"`
+	Stream        bool              `json:"stream,omitempty"`
+	Tools         []map[string]any  `json:"tools,omitempty"`
+	ToolChoice    *ToolChoice       `json:"tool_choice,omitempty"`
+	Thinking      *Thinking         `json:"thinking,omitempty"`
+	MCPServers    []MCPServerConfig `json:"mcp_servers,omitempty"`
+	Metadata      *Metadata         `json:"metadata,omitempty"`
+	ServiceTier   string            `json:"service_tier,omitempty"`
+}
+
+// Service tiers that may be requested. With "auto", Priority Tier capacity is
+// used when available. Learn more:
+// https://docs.anthropic.com/en/api/service-tiers
+const (
+	ServiceTierAuto         = "auto"
+	ServiceTierStandardOnly = "standard_only"
+)
+
+// Metadata describes the request. The user ID should be an opaque identifier,
+// such as a hash, that Anthropic may use to help detect abuse.
+type Metadata struct {
+	UserID string `json:"user_id,omitempty"`
 }
 
 // Usage contains token usage information for an LLM response.
@@ -83,6 +118,16 @@ func (u *Usage) Add(other *Usage) {
 	u.CacheReadInputTokens += other.CacheReadInputTokens
 }
 
+// CacheHitRatio returns the fraction of input tokens that were read from the
+// prompt cache, between 0 and 1.
+func (u *Usage) CacheHitRatio() float64 {
+	total := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	if total == 0 {
+		return 0
+	}
+	return float64(u.CacheReadInputTokens) / float64(total)
+}
+
 // Option is a function that is used to adjust LLM configuration.
 type Option func(*Client)
 
@@ -128,12 +173,27 @@ func WithBaseWait(baseWait time.Duration) Option {
 	}
 }
 
+// WithMaxRetryWait sets the maximum time to wait before retrying when the
+// server requests a delay via the retry-after or rate limit headers.
+func WithMaxRetryWait(maxWait time.Duration) Option {
+	return func(p *Client) {
+		p.maxRetryWait = maxWait
+	}
+}
+
 func WithVersion(version string) Option {
 	return func(p *Client) {
 		p.version = version
 	}
 }
 
+// WithMaxTurns sets the maximum number of LLM calls made by Run.
+func WithMaxTurns(maxTurns int) Option {
+	return func(p *Client) {
+		p.maxTurns = maxTurns
+	}
+}
+
 // WithTools sets the tools available to the client.
 func WithTools(tools ...ToolInterface) Option {
 	return func(p *Client) {
@@ -148,6 +208,85 @@ func WithToolChoice(choice *ToolChoice) Option {
 	}
 }
 
+// WithTemperature sets the sampling temperature.
+func WithTemperature(temperature float64) Option {
+	return func(p *Client) {
+		p.Temperature = &temperature
+	}
+}
+
+// WithTopP sets the nucleus sampling threshold. It is generally recommended
+// to set either the temperature or top p, but not both.
+func WithTopP(topP float64) Option {
+	return func(p *Client) {
+		p.TopP = &topP
+	}
+}
+
+// WithTopK limits sampling to the k most likely tokens.
+func WithTopK(topK int) Option {
+	return func(p *Client) {
+		p.TopK = &topK
+	}
+}
+
+// WithStopSequences sets custom text sequences that stop generation. When one
+// is generated, the response has stop reason StopReasonStopSequence and its
+// StopSequence field holds the matched sequence.
+func WithStopSequences(sequences ...string) Option {
+	return func(p *Client) {
+		p.StopSequences = sequences
+	}
+}
+
+// WithServiceTier sets the service tier, either ServiceTierAuto or
+// ServiceTierStandardOnly.
+func WithServiceTier(tier string) Option {
+	return func(p *Client) {
+		p.ServiceTier = tier
+	}
+}
+
+// WithMetadata sets the metadata sent with each request.
+func WithMetadata(metadata *Metadata) Option {
+	return func(p *Client) {
+		p.Metadata = metadata
+	}
+}
+
+// WithUserID sets the user ID in the metadata sent with each request.
+func WithUserID(userID string) Option {
+	return func(p *Client) {
+		metadata := Metadata{}
+		if p.Metadata != nil {
+			metadata = *p.Metadata
+		}
+		metadata.UserID = userID
+		p.Metadata = &metadata
+	}
+}
+
+// WithRequestHeaders adds HTTP headers to each request, replacing any
+// existing values for the same keys.
+func WithRequestHeaders(header http.Header) Option {
+	return func(p *Client) {
+		// Clone so that clients sharing the headers are not modified
+		headers := p.RequestHeaders.Clone()
+		if headers == nil {
+			headers = http.Header{}
+		}
+		for key, values := range header {
+			headers[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+		}
+		p.RequestHeaders = headers
+	}
+}
+
+// WithHeader sets an HTTP header on each request.
+func WithHeader(key, value string) Option {
+	return WithRequestHeaders(http.Header{key: {value}})
+}
+
 // WithSystemPrompt sets the system prompt for the client.
 func WithSystemPrompt(prompt string) Option {
 	return func(p *Client) {
@@ -155,12 +294,80 @@ func WithSystemPrompt(prompt string) Option {
 	}
 }
 
+// WithSystemBlocks sets the system prompt as a sequence of text blocks. This
+// allows setting cache control on part of the system prompt, for example to
+// cache a large static prefix while the blocks that follow it change. If a
+// system prompt string is also set, it is sent as the first block.
+func WithSystemBlocks(blocks ...*TextContent) Option {
+	return func(p *Client) {
+		p.SystemBlocks = blocks
+	}
+}
+
+// WithResponseFormat requests structured output from the LLM. For JSON
+// formats, the LLM is forced to call a tool whose input schema matches the
+// format and the tool input is returned as the response text. Use
+// Message.DecodeInto to decode it.
+func WithResponseFormat(format *ResponseFormat) Option {
+	return func(p *Client) {
+		p.ResponseFormat = format
+	}
+}
+
+// WithPrefill sets text that is sent as the start of the assistant's response.
+// The prefill is prepended to the first text block of the response, so that
+// callers see the complete output. If closingTag is set, Generate and
+// StreamToResponse return an error if the response text does not contain it.
+// The closing tag is not checked by Stream, since the text is not complete
+// until the stream ends.
+func WithPrefill(prefill, closingTag string) Option {
+	return func(p *Client) {
+		p.Prefill = prefill
+		p.PrefillClosingTag = closingTag
+	}
+}
+
+// WithReasoningBudget enables extended thinking with the given token budget.
+// The budget must be at least MinReasoningBudget and less than max tokens.
+func WithReasoningBudget(budget int) Option {
+	return func(p *Client) {
+		p.ReasoningBudget = &budget
+	}
+}
+
+// WithReasoningEffort enables extended thinking with a token budget derived
+// from the effort level. See ReasoningEffortBudgets. An explicit reasoning
+// budget takes precedence over the effort level.
+func WithReasoningEffort(effort ReasoningEffort) Option {
+	return func(p *Client) {
+		p.ReasoningEffort = effort
+	}
+}
+
+// WithPartialToolInputCallback sets a callback that is called while streaming
+// each time more of a tool's input is received. See
+// StreamIterator.PartialToolInput.
+func WithPartialToolInputCallback(callback PartialToolInputCallback) Option {
+	return func(p *Client) {
+		p.PartialToolInputCallback = callback
+	}
+}
+
+// ClientError is returned when the Anthropic API responds with an error.
+// Use errors.Is with the sentinel errors such as ErrRateLimited or
+// ErrOverloaded to check for specific kinds of errors.
 type ClientError struct {
 	statusCode int
 	body       string
+	header     http.Header
+	apiError   *APIError
 }
 
 func (e *ClientError) Error() string {
+	if e.apiError != nil {
+		return fmt.Sprintf("provider api error (status %d, %s): %s",
+			e.statusCode, e.apiError.Type, e.apiError.Message)
+	}
 	return fmt.Sprintf("provider api error (status %d): %s", e.statusCode, e.body)
 }
 
@@ -168,12 +375,102 @@ func (e *ClientError) StatusCode() int {
 	return e.statusCode
 }
 
+// Body returns the raw body of the error response.
+func (e *ClientError) Body() string {
+	return e.body
+}
+
+// ErrorType returns the error type reported by the API, e.g.
+// "overloaded_error". Empty if the response body was not an API error.
+func (e *ClientError) ErrorType() ErrorType {
+	if e.apiError == nil {
+		return ""
+	}
+	return e.apiError.Type
+}
+
+// Message returns the error message reported by the API. Falls back to the
+// raw response body if it was not an API error.
+func (e *ClientError) Message() string {
+	if e.apiError == nil {
+		return e.body
+	}
+	return e.apiError.Message
+}
+
+// RequestID returns the ID of the failed request, from the request-id
+// response header. Include this when reporting issues to Anthropic.
+func (e *ClientError) RequestID() string {
+	if e.header == nil {
+		return ""
+	}
+	return e.header.Get("request-id")
+}
+
+// Is reports whether the error matches one of the sentinel errors, such as
+// ErrRateLimited, based on the API error type or the status code.
+func (e *ClientError) Is(target error) bool {
+	if sentinel, ok := errorTypeSentinels[e.ErrorType()]; ok {
+		return sentinel == target
+	}
+	return statusCodeSentinels[e.statusCode] == target
+}
+
 func (e *ClientError) IsRecoverable() bool {
 	return ShouldRetry(e.statusCode)
 }
 
+// Header returns the headers of the response that caused the error, if any.
+func (e *ClientError) Header() http.Header {
+	return e.header
+}
+
+// RateLimits returns the rate limits reported in the response headers, or nil
+// if they were not reported.
+func (e *ClientError) RateLimits() *RateLimits {
+	if e.header == nil {
+		return nil
+	}
+	return ParseRateLimits(e.header)
+}
+
+// RetryAfter returns how long the server asked the client to wait before
+// retrying. This uses the retry-after header if present, and otherwise the
+// reset time of any exhausted rate limits. Returns zero if the server did
+// not indicate a delay.
+func (e *ClientError) RetryAfter() time.Duration {
+	if e.header == nil {
+		return 0
+	}
+	now := time.Now()
+	if wait := parseRetryAfter(e.header, now); wait > 0 {
+		return wait
+	}
+	if e.statusCode == http.StatusTooManyRequests {
+		if limits := ParseRateLimits(e.header); limits != nil {
+			return limits.exhaustedResetDelay(now)
+		}
+	}
+	return 0
+}
+
+// NewError creates an error for the given status code and response body. A
+// body containing an API error envelope is parsed to determine the error
+// type and message.
 func NewError(statusCode int, body string) *ClientError {
-	return &ClientError{statusCode: statusCode, body: body}
+	return &ClientError{
+		statusCode: statusCode,
+		body:       body,
+		apiError:   parseAPIError(body),
+	}
+}
+
+// NewErrorWithHeader creates an error that retains the response headers, so
+// that the request ID, retry-after and rate limit information is available.
+func NewErrorWithHeader(statusCode int, body string, header http.Header) *ClientError {
+	err := NewError(statusCode, body)
+	err.header = header
+	return err
 }
 
 // ShouldRetry determines if the given status code should trigger a retry
@@ -182,7 +479,8 @@ func ShouldRetry(statusCode int) bool {
 		statusCode == http.StatusInternalServerError || // 500
 		statusCode == http.StatusServiceUnavailable || // 503
 		statusCode == http.StatusGatewayTimeout || // 504
-		statusCode == 520 // Cloudflare
+		statusCode == 520 || // Cloudflare
+		statusCode == StatusOverloaded // 529
 }
 
 type Client struct {
@@ -193,8 +491,12 @@ type Client struct {
 	maxTokens          int
 	maxRetries         int
 	retryBaseWait      time.Duration
+	maxRetryWait       time.Duration
 	version            string
+	maxTurns           int
+	logger             *slog.Logger
 	SystemPrompt       string                   `json:"system_prompt,omitempty"`
+	SystemBlocks       []*TextContent           `json:"system_blocks,omitempty"`
 	Tools              []ToolInterface          `json:"tools,omitempty"`
 	ToolChoice         *ToolChoice              `json:"tool_choice,omitempty"`
 	ParallelToolCalls  *bool                    `json:"parallel_tool_calls,omitempty"`
@@ -203,13 +505,18 @@ type Client struct {
 	PrefillClosingTag  string                   `json:"prefill_closing_tag,omitempty"`
 	MaxTokens          *int                     `json:"max_tokens,omitempty"`
 	Temperature        *float64                 `json:"temperature,omitempty"`
+	TopP               *float64                 `json:"top_p,omitempty"`
+	TopK               *int                     `json:"top_k,omitempty"`
+	StopSequences      []string                 `json:"stop_sequences,omitempty"`
 	PresencePenalty    *float64                 `json:"presence_penalty,omitempty"`
 	FrequencyPenalty   *float64                 `json:"frequency_penalty,omitempty"`
 	ReasoningBudget    *int                     `json:"reasoning_budget,omitempty"`
 	ReasoningEffort    ReasoningEffort          `json:"reasoning_effort,omitempty"`
 	Features           []string                 `json:"features,omitempty"`
 	RequestHeaders     http.Header              `json:"request_headers,omitempty"`
+	Metadata           *Metadata                `json:"metadata,omitempty"`
 	Caching            *bool                    `json:"caching,omitempty"`
+	CacheTTL           CacheTTL                 `json:"cache_ttl,omitempty"`
 	PreviousResponseID string                   `json:"previous_response_id,omitempty"`
 	ServiceTier        string                   `json:"service_tier,omitempty"`
 	ClientOptions      map[string]interface{}   `json:"client_options,omitempty"`
@@ -217,6 +524,8 @@ type Client struct {
 	Messages           Messages                 `json:"messages"`
 	Client             *http.Client             `json:"-"`
 	SSECallback        ServerSentEventsCallback `json:"-"`
+
+	PartialToolInputCallback PartialToolInputCallback `json:"-"`
 }
 
 // Apply applies the given options to the config.
@@ -225,6 +225,7 @@ func TestClientError_IsRecoverable(t *testing.T) {
 		{503, true},  // Service Unavailable
 		{504, true},  // Gateway Timeout
 		{520, true},  // Cloudflare
+		{529, true},  // Overloaded
 		{400, false}, // Bad Request
 		{401, false}, // Unauthorized
 		{403, false}, // Forbidden
@@ -262,6 +263,7 @@ func TestShouldRetry(t *testing.T) {
 		{503, true},  // Service Unavailable
 		{504, true},  // Gateway Timeout
 		{520, true},  // Cloudflare
+		{529, true},  // Overloaded
 		{400, false}, // Bad Request
 		{401, false}, // Unauthorized
 		{403, false}, // Forbidden
@@ -48,3 +48,11 @@ func addPrefill(blocks []Content, prefill, closingTag string) error {
 	}
 	return fmt.Errorf("no text content found in message")
 }
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func stringPtr(s string) *string {
+	return &s
+}